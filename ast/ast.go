@@ -10,6 +10,9 @@ type Node interface {
 
 	// returns a string representation of the statements in the ast
 	ToString(int) string
+
+	// returns the line and column of the token which generated the node
+	Position() (int, int)
 }
 
 type Expression interface {
@@ -43,3 +46,11 @@ func (p *Program) TokenLiteral() string {
 		return ""
 	}
 }
+
+func (p *Program) Position() (int, int) {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Position()
+	} else {
+		return 0, 0
+	}
+}
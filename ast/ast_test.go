@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -36,3 +37,218 @@ func TestString(t *testing.T) {
 		t.Errorf("program.ToString() wrong.\nExpected:\n%s\nGot:\n%s", expected, actual)
 	}
 }
+
+func TestJSONRoundTrip(t *testing.T) {
+	program := &Program{
+		Statements: []Statement{
+			&ExpressionStatement{
+				Token: tokens.Token{Type: tokens.IF, Literal: "si", Line: 1, Column: 1},
+				Expression: &IfExpression{
+					Token: tokens.Token{Type: tokens.IF, Literal: "si", Line: 1, Column: 1},
+					Condition: &InfixExpression{
+						Token:    tokens.Token{Type: tokens.LT, Literal: "<", Line: 1, Column: 6},
+						Operator: "<",
+						Left:     &IntegerLiteral{Token: tokens.Token{Type: tokens.NUMBER, Literal: "1"}, Value: 1},
+						Right: &PrefixExpression{
+							Token:    tokens.Token{Type: tokens.MINUS, Literal: "-"},
+							Operator: "-",
+							Right:    &IntegerLiteral{Token: tokens.Token{Type: tokens.NUMBER, Literal: "0x2"}, Value: 2},
+						},
+					},
+					Consequence: &BlockStatement{
+						Token: tokens.Token{Type: tokens.LBRAC, Literal: "{"},
+						Statements: []Statement{
+							&ReturnStatement{
+								Token:       tokens.Token{Type: tokens.RETURN, Literal: "retorna"},
+								ReturnValue: &StringLiteral{Token: tokens.Token{Type: tokens.STRING, Literal: "hola"}, Value: "hola"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	data, err := EncodeJSON(program)
+	if err != nil {
+		t.Fatalf("Unexpected error encoding: %s", err)
+	}
+
+	decoded, err := DecodeJSON(data)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding: %s", err)
+	}
+
+	if decoded.ToString(0) != program.ToString(0) {
+		t.Fatalf("Decoded program differs.\nExpected:\n%s\nGot:\n%s", program.ToString(0), decoded.ToString(0))
+	}
+
+	exp := decoded.Statements[0].(*ExpressionStatement).Expression.(*IfExpression)
+	if exp.Alternative != nil {
+		t.Errorf("Expected a nil alternative. Got %v", exp.Alternative)
+	}
+
+	if exp.Condition.(*InfixExpression).Token.Column != 6 {
+		t.Errorf("Token position was not preserved: %+v", exp.Condition.(*InfixExpression).Token)
+	}
+
+	// re-encoding generates the same document
+	again, _ := EncodeJSON(decoded)
+	if string(again) != string(data) {
+		t.Errorf("Expected:\n%s\nGot:\n%s", data, again)
+	}
+}
+
+func TestJSONDecodeErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`{"node": "Unknown", "token": {}}`, "Unknown node type: 'Unknown'"},
+		{`{"node": "Identifier", "token": {}}`, "Missing field 'value' on 'Identifier' node"},
+		{`{"node": "Identifier", "token": {}, "value": "x"}`, "Expected a 'Program' node. Got *ast.Identifier"},
+		{
+			`{"node": "Program", "token": {}, "statements": [{"node": "Identifier", "token": {}, "value": "x"}]}`,
+			"Field 'statements' on 'Program' node must only contain statements",
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := DecodeJSON([]byte(tc.input))
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("Expected error '%s'. Got %v", tc.expected, err)
+		}
+	}
+}
+
+func TestJSONMissingFields(t *testing.T) {
+	ident := `{"node": "Identifier", "token": {}, "value": "x"}`
+	number := `{"node": "IntegerLiteral", "token": {}, "value": 2}`
+	str := `{"node": "StringLiteral", "token": {}, "value": "x"}`
+	block := `{"node": "BlockStatement", "token": {}, "statements": []}`
+
+	// every node with its required children, which are removed one by one
+	testCases := []struct {
+		node     string
+		fields   map[string]string
+		required []string
+	}{
+		{"VarStatement", map[string]string{"identifier": ident, "value": number}, []string{"identifier", "value"}},
+		{"ReturnStatement", map[string]string{"value": number}, []string{"value"}},
+		{"ExpressionStatement", map[string]string{"expression": number}, []string{"expression"}},
+		{
+			"FunctionStatement",
+			map[string]string{"identifier": ident, "parameters": "[]", "body": block},
+			[]string{"identifier", "body"},
+		},
+		{"PrefixExpression", map[string]string{"operator": `"-"`, "right": number}, []string{"right"}},
+		{
+			"InfixExpression",
+			map[string]string{"operator": `"+"`, "left": number, "right": number},
+			[]string{"left", "right"},
+		},
+		{
+			"IfExpression",
+			map[string]string{"condition": ident, "consequence": block, "alternative": "null"},
+			[]string{"condition", "consequence"},
+		},
+		{"AnonymousFunction", map[string]string{"parameters": "[]", "body": block}, []string{"body"}},
+		{"FunctionCall", map[string]string{"function": ident, "arguments": "[]"}, []string{"function"}},
+		{"ForLoop", map[string]string{"iterations": number, "body": block}, []string{"iterations", "body"}},
+		{
+			"TryStatement",
+			map[string]string{"body": block, "identifier": ident, "catch": block, "finally": "null"},
+			[]string{"body", "identifier", "catch"},
+		},
+		{"ThrowStatement", map[string]string{"value": str}, []string{"value"}},
+		{"MemberExpression", map[string]string{"object": ident, "member": ident}, []string{"object", "member"}},
+		{"TestStatement", map[string]string{"name": str, "body": block}, []string{"name", "body"}},
+		{"ImportStatement", map[string]string{"path": str, "name": ident}, []string{"path", "name"}},
+	}
+
+	encode := func(node string, fields map[string]string) string {
+		object := fmt.Sprintf(`{"node": %q, "token": {}`, node)
+		for name, value := range fields {
+			object += fmt.Sprintf(`, %q: %s`, name, value)
+		}
+		return object + "}"
+	}
+
+	// expressions are wrapped on statements, as programs only contain statements
+	program := func(node string, fields map[string]string) string {
+		stmt := encode(node, fields)
+		if !strings.HasSuffix(node, "Statement") {
+			stmt = encode("ExpressionStatement", map[string]string{"expression": stmt})
+		}
+		return `{"node": "Program", "token": {}, "statements": [` + stmt + `]}`
+	}
+
+	for _, tc := range testCases {
+		if _, err := DecodeJSON([]byte(program(tc.node, tc.fields))); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.node, err)
+		}
+
+		for _, name := range tc.required {
+			fields := map[string]string{}
+			for field, value := range tc.fields {
+				if field != name {
+					fields[field] = value
+				}
+			}
+
+			expected := fmt.Sprintf("Missing field '%s' on '%s' node", name, tc.node)
+			if _, err := DecodeJSON([]byte(program(tc.node, fields))); err == nil || err.Error() != expected {
+				t.Errorf("Expected error '%s'. Got %v", expected, err)
+			}
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	ident := func(name string) *Identifier {
+		return NewIdentifier(tokens.Token{Type: tokens.IDENT, Literal: name})
+	}
+
+	// si (x) { f(y) }
+	program := &Program{
+		Statements: []Statement{
+			&ExpressionStatement{
+				Expression: &IfExpression{
+					Condition: ident("x"),
+					Consequence: &BlockStatement{
+						Statements: []Statement{
+							&ExpressionStatement{
+								Expression: &FunctionCall{Identifier: ident("f"), Arguments: []Expression{ident("y")}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	visited := []string{}
+	Walk(program, func(node Node) bool {
+		if ident, ok := node.(*Identifier); ok {
+			visited = append(visited, ident.Value)
+		}
+		// the arguments of the calls are skipped
+		_, isCall := node.(*FunctionCall)
+		return !isCall
+	})
+
+	if strings.Join(visited, " ") != "x" {
+		t.Errorf("expected to visit only x. Got %v", visited)
+	}
+
+	count := 0
+	Walk(program, func(Node) bool {
+		count++
+		return true
+	})
+
+	// program, 2 expression statements, if, x, block, call, f and y
+	if count != 9 {
+		t.Errorf("expected 9 nodes. Got %d", count)
+	}
+}
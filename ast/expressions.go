@@ -27,6 +27,9 @@ func (i *Identifier) expressionNode() {}
 func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
+func (i *Identifier) Position() (int, int) {
+	return i.Token.Line, i.Token.Column
+}
 func (i *Identifier) ToString(lvl int) string {
 	indent := strings.Repeat("  ", lvl)
 	return fmt.Sprintf("%sIdentifier: %s\n", indent, i.Value)
@@ -38,7 +41,20 @@ type IntegerLiteral struct {
 }
 
 func NewInteger(t tokens.Token) *IntegerLiteral {
-	value, err := strconv.ParseInt(t.Literal, 0, 64)
+	// the base is explicit, so decimal literals with leading zeros are not read as octal
+	base, digits := 10, t.Literal
+	if len(digits) > 1 && digits[0] == '0' {
+		switch digits[1] | 0x20 { // lower case the prefix
+		case 'x':
+			base, digits = 16, digits[2:]
+		case 'b':
+			base, digits = 2, digits[2:]
+		case 'o':
+			base, digits = 8, digits[2:]
+		}
+	}
+
+	value, err := strconv.ParseInt(strings.ReplaceAll(digits, "_", ""), base, 64)
 	if err != nil {
 		return nil
 	}
@@ -52,6 +68,9 @@ func (i *IntegerLiteral) expressionNode() {}
 func (i *IntegerLiteral) TokenLiteral() string {
 	return i.Token.Literal
 }
+func (i *IntegerLiteral) Position() (int, int) {
+	return i.Token.Line, i.Token.Column
+}
 func (i *IntegerLiteral) ToString(lvl int) string {
 	indent := strings.Repeat("  ", lvl)
 	return fmt.Sprintf("%sInteger: %s\n", indent, i.TokenLiteral())
@@ -72,11 +91,47 @@ func (i *StringLiteral) expressionNode() {}
 func (i *StringLiteral) TokenLiteral() string {
 	return i.Token.Literal
 }
+func (i *StringLiteral) Position() (int, int) {
+	return i.Token.Line, i.Token.Column
+}
 func (i *StringLiteral) ToString(lvl int) string {
 	indent := strings.Repeat("  ", lvl)
 	return fmt.Sprintf("%sString: %s\n", indent, i.TokenLiteral())
 }
 
+// A string literal with embedded expressions, like "Hola ${nombre}". The parts
+// are evaluated in order and concatenated; the literal text between the
+// interpolations is kept as *StringLiteral parts.
+type InterpolatedString struct {
+	Parts []Expression
+	Token tokens.Token // the "INTERP_START" token
+}
+
+func NewInterpolatedString(t tokens.Token) *InterpolatedString {
+	return &InterpolatedString{
+		Token: t,
+	}
+}
+func (i *InterpolatedString) expressionNode() {}
+func (i *InterpolatedString) TokenLiteral() string {
+	return i.Token.Literal
+}
+func (i *InterpolatedString) Position() (int, int) {
+	return i.Token.Line, i.Token.Column
+}
+func (i *InterpolatedString) ToString(lvl int) string {
+	var buffer bytes.Buffer
+
+	indent := strings.Repeat("  ", lvl)
+	buffer.WriteString(indent + "interpolated string:\n")
+	buffer.WriteString(indent + "  parts:\n")
+	for _, part := range i.Parts {
+		buffer.WriteString(part.ToString(lvl + 2))
+	}
+
+	return buffer.String()
+}
+
 type PrefixExpression struct {
 	Right    Expression
 	Operator string
@@ -87,6 +142,9 @@ func (p *PrefixExpression) expressionNode() {}
 func (p *PrefixExpression) TokenLiteral() string {
 	return p.Token.Literal
 }
+func (p *PrefixExpression) Position() (int, int) {
+	return p.Token.Line, p.Token.Column
+}
 func (p *PrefixExpression) ToString(lvl int) string {
 	var out bytes.Buffer
 
@@ -110,6 +168,9 @@ func (i *InfixExpression) expressionNode() {}
 func (i *InfixExpression) TokenLiteral() string {
 	return i.Token.Literal
 }
+func (i *InfixExpression) Position() (int, int) {
+	return i.Token.Line, i.Token.Column
+}
 func (i *InfixExpression) ToString(lvl int) string {
 	var out bytes.Buffer
 
@@ -142,6 +203,9 @@ func (b *Boolean) expressionNode() {}
 func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
+func (b *Boolean) Position() (int, int) {
+	return b.Token.Line, b.Token.Column
+}
 func (b *Boolean) ToString(lvl int) string {
 	indent := strings.Repeat("  ", lvl)
 	return fmt.Sprintf("%sBool: %s\n", indent, b.TokenLiteral())
@@ -164,6 +228,9 @@ func (i *IfExpression) expressionNode() {}
 func (i *IfExpression) TokenLiteral() string {
 	return i.Token.Literal
 }
+func (i *IfExpression) Position() (int, int) {
+	return i.Token.Line, i.Token.Column
+}
 func (i *IfExpression) ToString(lvl int) string {
 	var buffer bytes.Buffer
 
@@ -198,6 +265,9 @@ func (f *AnonymousFunction) expressionNode() {}
 func (f *AnonymousFunction) TokenLiteral() string {
 	return f.Token.Literal
 }
+func (f *AnonymousFunction) Position() (int, int) {
+	return f.Token.Line, f.Token.Column
+}
 func (f *AnonymousFunction) ToString(lvl int) string {
 	var buffer bytes.Buffer
 
@@ -230,6 +300,9 @@ func (f *FunctionCall) expressionNode() {}
 func (f *FunctionCall) TokenLiteral() string {
 	return f.Token.Literal
 }
+func (f *FunctionCall) Position() (int, int) {
+	return f.Token.Line, f.Token.Column
+}
 func (f *FunctionCall) ToString(lvl int) string {
 	var buffer bytes.Buffer
 
@@ -262,6 +335,9 @@ func (f *ForLoop) expressionNode() {}
 func (f *ForLoop) TokenLiteral() string {
 	return f.Token.Literal
 }
+func (f *ForLoop) Position() (int, int) {
+	return f.Token.Line, f.Token.Column
+}
 func (f *ForLoop) ToString(lvl int) string {
 	var buffer bytes.Buffer
 
@@ -274,3 +350,30 @@ func (f *ForLoop) ToString(lvl int) string {
 
 	return buffer.String()
 }
+
+// Access to a member of a value, like "e.mensaje"
+type MemberExpression struct {
+	Object Expression
+	Member *Identifier
+	Token  tokens.Token // the "." token
+}
+
+func (m *MemberExpression) expressionNode() {}
+func (m *MemberExpression) TokenLiteral() string {
+	return m.Token.Literal
+}
+func (m *MemberExpression) Position() (int, int) {
+	return m.Token.Line, m.Token.Column
+}
+func (m *MemberExpression) ToString(lvl int) string {
+	var buffer bytes.Buffer
+
+	indent := strings.Repeat("  ", lvl)
+	buffer.WriteString(indent + "member expression:\n")
+	buffer.WriteString(indent + " object:\n")
+	buffer.WriteString(m.Object.ToString(lvl + 2))
+	buffer.WriteString(indent + " member:\n")
+	buffer.WriteString(m.Member.ToString(lvl + 2))
+
+	return buffer.String()
+}
@@ -0,0 +1,585 @@
+/*
+JSON serialization of the AST, used to feed external tools.
+
+Every node is encoded as an object with a "node" field holding the name of its type
+(like "InfixExpression") and a "token" field holding the token which generated it:
+
+	{"type": "PLUS", "literal": "+", "line": 1, "column": 3}
+
+The rest of the fields depend on the node type. Missing optional children (like the
+alternative of an if expression) are encoded as null.
+
+	Program              statements: [Statement]
+	VarStatement         identifier: Identifier, value: Expression
+	ReturnStatement      value: Expression
+	ExpressionStatement  expression: Expression
+	BlockStatement       statements: [Statement]
+	FunctionStatement    identifier: Identifier, parameters: [Identifier], body: BlockStatement
+	Identifier           value: string
+	IntegerLiteral       value: number
+	StringLiteral        value: string
+	InterpolatedString   parts: [Expression]
+	Boolean              value: bool
+	PrefixExpression     operator: string, right: Expression
+	InfixExpression      operator: string, left: Expression, right: Expression
+	IfExpression         condition: Expression, consequence: BlockStatement, alternative: BlockStatement
+	AnonymousFunction    parameters: [Identifier], body: BlockStatement
+	FunctionCall         function: Expression, arguments: [Expression]
+	ForLoop              iterations: IntegerLiteral, body: BlockStatement
+	TryStatement         body: BlockStatement, identifier: Identifier, catch: BlockStatement, finally: BlockStatement
+	ThrowStatement       value: Expression
+	MemberExpression     object: Expression, member: Identifier
+	TestStatement        name: StringLiteral, body: BlockStatement
+	ImportStatement      path: StringLiteral, name: Identifier
+*/
+
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sl2.0/tokens"
+)
+
+type jsonObject map[string]interface{}
+
+// Returns the JSON representation of the given node and all of its children
+func EncodeJSON(node Node) ([]byte, error) {
+	return json.Marshal(encodeNode(node))
+}
+
+// Rebuilds a program from the JSON generated by EncodeJSON
+func DecodeJSON(data []byte) (*Program, error) {
+	node, err := decodeNode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	program, ok := node.(*Program)
+	if !ok {
+		return nil, fmt.Errorf("Expected a 'Program' node. Got %T", node)
+	}
+
+	return program, nil
+}
+
+// --------------
+// -- Encoding --
+// --------------
+
+func newJSONObject(kind string, t tokens.Token) jsonObject {
+	return jsonObject{"node": kind, "token": t}
+}
+
+func encodeNode(node Node) interface{} {
+	switch node := node.(type) {
+	case *Program:
+		obj := newJSONObject("Program", tokens.Token{})
+		obj["statements"] = encodeStatements(node.Statements)
+		return obj
+
+	case *VarStatement:
+		obj := newJSONObject("VarStatement", node.Token)
+		obj["identifier"] = encodeIdentifier(node.Identifier)
+		obj["value"] = encodeNode(node.Value)
+		return obj
+
+	case *ReturnStatement:
+		obj := newJSONObject("ReturnStatement", node.Token)
+		obj["value"] = encodeNode(node.ReturnValue)
+		return obj
+
+	case *ExpressionStatement:
+		obj := newJSONObject("ExpressionStatement", node.Token)
+		obj["expression"] = encodeNode(node.Expression)
+		return obj
+
+	case *BlockStatement:
+		if node == nil {
+			return nil
+		}
+		obj := newJSONObject("BlockStatement", node.Token)
+		obj["statements"] = encodeStatements(node.Statements)
+		return obj
+
+	case *FunctionStatement:
+		obj := newJSONObject("FunctionStatement", node.Token)
+		obj["identifier"] = encodeIdentifier(node.Identifier)
+		obj["parameters"] = encodeIdentifiers(node.Parameters)
+		obj["body"] = encodeNode(node.Body)
+		return obj
+
+	case *Identifier:
+		return encodeIdentifier(node)
+
+	case *IntegerLiteral:
+		if node == nil {
+			return nil
+		}
+		obj := newJSONObject("IntegerLiteral", node.Token)
+		obj["value"] = node.Value
+		return obj
+
+	case *StringLiteral:
+		obj := newJSONObject("StringLiteral", node.Token)
+		obj["value"] = node.Value
+		return obj
+
+	case *InterpolatedString:
+		obj := newJSONObject("InterpolatedString", node.Token)
+		obj["parts"] = encodeExpressions(node.Parts)
+		return obj
+
+	case *Boolean:
+		obj := newJSONObject("Boolean", node.Token)
+		obj["value"] = node.Value
+		return obj
+
+	case *PrefixExpression:
+		obj := newJSONObject("PrefixExpression", node.Token)
+		obj["operator"] = node.Operator
+		obj["right"] = encodeNode(node.Right)
+		return obj
+
+	case *InfixExpression:
+		obj := newJSONObject("InfixExpression", node.Token)
+		obj["operator"] = node.Operator
+		obj["left"] = encodeNode(node.Left)
+		obj["right"] = encodeNode(node.Right)
+		return obj
+
+	case *IfExpression:
+		obj := newJSONObject("IfExpression", node.Token)
+		obj["condition"] = encodeNode(node.Condition)
+		obj["consequence"] = encodeNode(node.Consequence)
+		obj["alternative"] = encodeNode(node.Alternative)
+		return obj
+
+	case *AnonymousFunction:
+		obj := newJSONObject("AnonymousFunction", node.Token)
+		obj["parameters"] = encodeIdentifiers(node.Parameters)
+		obj["body"] = encodeNode(node.Body)
+		return obj
+
+	case *FunctionCall:
+		obj := newJSONObject("FunctionCall", node.Token)
+		obj["function"] = encodeNode(node.Identifier)
+		obj["arguments"] = encodeExpressions(node.Arguments)
+		return obj
+
+	case *ForLoop:
+		obj := newJSONObject("ForLoop", node.Token)
+		obj["iterations"] = encodeNode(&node.Iterations)
+		obj["body"] = encodeNode(node.Body)
+		return obj
+
+	case *TryStatement:
+		obj := newJSONObject("TryStatement", node.Token)
+		obj["body"] = encodeNode(node.Body)
+		obj["identifier"] = encodeIdentifier(node.Identifier)
+		obj["catch"] = encodeNode(node.Catch)
+		obj["finally"] = encodeNode(node.Finally)
+		return obj
+
+	case *ThrowStatement:
+		obj := newJSONObject("ThrowStatement", node.Token)
+		obj["value"] = encodeNode(node.Value)
+		return obj
+
+	case *MemberExpression:
+		obj := newJSONObject("MemberExpression", node.Token)
+		obj["object"] = encodeNode(node.Object)
+		obj["member"] = encodeIdentifier(node.Member)
+		return obj
+
+	case *TestStatement:
+		obj := newJSONObject("TestStatement", node.Token)
+		obj["name"] = encodeNode(node.Name)
+		obj["body"] = encodeNode(node.Body)
+		return obj
+
+	case *ImportStatement:
+		obj := newJSONObject("ImportStatement", node.Token)
+		obj["path"] = encodeNode(node.Path)
+		obj["name"] = encodeIdentifier(node.Name)
+		return obj
+	}
+
+	return nil
+}
+
+func encodeIdentifier(ident *Identifier) interface{} {
+	if ident == nil {
+		return nil
+	}
+
+	obj := newJSONObject("Identifier", ident.Token)
+	obj["value"] = ident.Value
+	return obj
+}
+
+func encodeIdentifiers(idents []*Identifier) []interface{} {
+	list := []interface{}{}
+	for _, ident := range idents {
+		list = append(list, encodeIdentifier(ident))
+	}
+	return list
+}
+
+func encodeStatements(stmts []Statement) []interface{} {
+	list := []interface{}{}
+	for _, stmt := range stmts {
+		list = append(list, encodeNode(stmt))
+	}
+	return list
+}
+
+func encodeExpressions(exps []Expression) []interface{} {
+	list := []interface{}{}
+	for _, exp := range exps {
+		list = append(list, encodeNode(exp))
+	}
+	return list
+}
+
+// --------------
+// -- Decoding --
+// --------------
+
+type jsonFields map[string]json.RawMessage
+
+// Decodes the fields of a single node. The first error found is kept, so the
+// decoding functions can be chained without checking errors on every step.
+type jsonDecoder struct {
+	fields jsonFields
+	kind   string
+	err    error
+}
+
+func (d *jsonDecoder) value(name string, v interface{}) {
+	if d.err != nil {
+		return
+	}
+
+	raw, ok := d.fields[name]
+	if !ok {
+		d.err = fmt.Errorf("Missing field '%s' on '%s' node", name, d.kind)
+		return
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		d.err = fmt.Errorf("Invalid field '%s' on '%s' node: %s", name, d.kind, err)
+	}
+}
+
+// Decodes a node that may be absent or null, like the alternative of an if
+func (d *jsonDecoder) optionalNode(name string) Node {
+	if d.err != nil {
+		return nil
+	}
+
+	node, err := decodeNode(d.fields[name])
+	if err != nil {
+		d.err = err
+	}
+
+	return node
+}
+
+func (d *jsonDecoder) node(name string) Node {
+	node := d.optionalNode(name)
+	if node == nil && d.err == nil {
+		d.err = fmt.Errorf("Missing field '%s' on '%s' node", name, d.kind)
+	}
+
+	return node
+}
+
+func (d *jsonDecoder) expression(name string) Expression {
+	node := d.node(name)
+	if node == nil {
+		return nil
+	}
+
+	exp, ok := node.(Expression)
+	if !ok {
+		d.err = fmt.Errorf("Field '%s' on '%s' node is not an expression", name, d.kind)
+	}
+
+	return exp
+}
+
+func (d *jsonDecoder) block(name string) *BlockStatement {
+	return d.toBlock(name, d.node(name))
+}
+
+func (d *jsonDecoder) optionalBlock(name string) *BlockStatement {
+	return d.toBlock(name, d.optionalNode(name))
+}
+
+func (d *jsonDecoder) toBlock(name string, node Node) *BlockStatement {
+	if node == nil {
+		return nil
+	}
+
+	block, ok := node.(*BlockStatement)
+	if !ok {
+		d.err = fmt.Errorf("Field '%s' on '%s' node is not a block statement", name, d.kind)
+	}
+
+	return block
+}
+
+func (d *jsonDecoder) identifier(name string) *Identifier {
+	return d.toIdentifier(name, d.node(name))
+}
+
+func (d *jsonDecoder) optionalIdentifier(name string) *Identifier {
+	return d.toIdentifier(name, d.optionalNode(name))
+}
+
+func (d *jsonDecoder) toIdentifier(name string, node Node) *Identifier {
+	if node == nil {
+		return nil
+	}
+
+	ident, ok := node.(*Identifier)
+	if !ok {
+		d.err = fmt.Errorf("Field '%s' on '%s' node is not an identifier", name, d.kind)
+	}
+
+	return ident
+}
+
+func (d *jsonDecoder) list(name string) []Node {
+	var raws []json.RawMessage
+	d.value(name, &raws)
+
+	var nodes []Node
+	for _, raw := range raws {
+		if d.err != nil {
+			return nil
+		}
+
+		node, err := decodeNode(raw)
+		if err != nil {
+			d.err = err
+			return nil
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
+func (d *jsonDecoder) statements(name string) []Statement {
+	stmts := []Statement{}
+	for _, node := range d.list(name) {
+		stmt, ok := node.(Statement)
+		if !ok {
+			d.err = fmt.Errorf("Field '%s' on '%s' node must only contain statements", name, d.kind)
+			return nil
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+func (d *jsonDecoder) expressions(name string) []Expression {
+	exps := []Expression{}
+	for _, node := range d.list(name) {
+		exp, ok := node.(Expression)
+		if !ok {
+			d.err = fmt.Errorf("Field '%s' on '%s' node must only contain expressions", name, d.kind)
+			return nil
+		}
+		exps = append(exps, exp)
+	}
+	return exps
+}
+
+func (d *jsonDecoder) identifiers(name string) []*Identifier {
+	var idents []*Identifier
+	for _, node := range d.list(name) {
+		ident, ok := node.(*Identifier)
+		if !ok {
+			d.err = fmt.Errorf("Field '%s' on '%s' node must only contain identifiers", name, d.kind)
+			return nil
+		}
+		idents = append(idents, ident)
+	}
+	return idents
+}
+
+func decodeNode(data json.RawMessage) (Node, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+
+	d := &jsonDecoder{}
+	if err := json.Unmarshal(data, &d.fields); err != nil {
+		return nil, err
+	}
+
+	d.value("node", &d.kind)
+
+	var token tokens.Token
+	d.value("token", &token)
+
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	var node Node
+
+	switch d.kind {
+	case "Program":
+		node = &Program{Statements: d.statements("statements")}
+
+	case "VarStatement":
+		node = &VarStatement{
+			Token:      token,
+			Identifier: d.identifier("identifier"),
+			Value:      d.expression("value"),
+		}
+
+	case "ReturnStatement":
+		node = &ReturnStatement{Token: token, ReturnValue: d.expression("value")}
+
+	case "ExpressionStatement":
+		node = &ExpressionStatement{Token: token, Expression: d.expression("expression")}
+
+	case "BlockStatement":
+		node = &BlockStatement{Token: token, Statements: d.statements("statements")}
+
+	case "FunctionStatement":
+		node = &FunctionStatement{
+			Token:      token,
+			Identifier: d.identifier("identifier"),
+			Parameters: d.identifiers("parameters"),
+			Body:       d.block("body"),
+		}
+
+	case "Identifier":
+		ident := &Identifier{Token: token}
+		d.value("value", &ident.Value)
+		node = ident
+
+	case "IntegerLiteral":
+		integer := &IntegerLiteral{Token: token}
+		d.value("value", &integer.Value)
+		node = integer
+
+	case "StringLiteral":
+		str := &StringLiteral{Token: token}
+		d.value("value", &str.Value)
+		node = str
+
+	case "InterpolatedString":
+		node = &InterpolatedString{Token: token, Parts: d.expressions("parts")}
+
+	case "Boolean":
+		boolean := &Boolean{Token: token}
+		d.value("value", &boolean.Value)
+		node = boolean
+
+	case "PrefixExpression":
+		prefix := &PrefixExpression{Token: token, Right: d.expression("right")}
+		d.value("operator", &prefix.Operator)
+		node = prefix
+
+	case "InfixExpression":
+		infix := &InfixExpression{
+			Token: token,
+			Left:  d.expression("left"),
+			Right: d.expression("right"),
+		}
+		d.value("operator", &infix.Operator)
+		node = infix
+
+	case "IfExpression":
+		node = &IfExpression{
+			Token:       token,
+			Condition:   d.expression("condition"),
+			Consequence: d.block("consequence"),
+			Alternative: d.optionalBlock("alternative"),
+		}
+
+	case "AnonymousFunction":
+		node = &AnonymousFunction{
+			Token:      token,
+			Parameters: d.identifiers("parameters"),
+			Body:       d.block("body"),
+		}
+
+	case "FunctionCall":
+		node = &FunctionCall{
+			Token:      token,
+			Identifier: d.expression("function"),
+			Arguments:  d.expressions("arguments"),
+		}
+
+	case "ForLoop":
+		loop := &ForLoop{Token: token, Body: d.block("body")}
+		if iterations, ok := d.node("iterations").(*IntegerLiteral); ok {
+			loop.Iterations = *iterations
+		} else if d.err == nil {
+			d.err = fmt.Errorf("Field 'iterations' on 'ForLoop' node is not an integer")
+		}
+		node = loop
+
+	case "TryStatement":
+		try := &TryStatement{
+			Token:      token,
+			Body:       d.block("body"),
+			Identifier: d.optionalIdentifier("identifier"),
+			Catch:      d.optionalBlock("catch"),
+			Finally:    d.optionalBlock("finally"),
+		}
+		// the catch block needs the identifier of the error, and one of the blocks is required
+		if d.err == nil && try.Catch != nil && try.Identifier == nil {
+			d.err = fmt.Errorf("Missing field 'identifier' on '%s' node", d.kind)
+		} else if d.err == nil && try.Catch == nil && try.Finally == nil {
+			d.err = fmt.Errorf("Missing field 'catch' on '%s' node", d.kind)
+		}
+		node = try
+
+	case "ThrowStatement":
+		node = &ThrowStatement{Token: token, Value: d.expression("value")}
+
+	case "MemberExpression":
+		node = &MemberExpression{
+			Token:  token,
+			Object: d.expression("object"),
+			Member: d.identifier("member"),
+		}
+
+	case "TestStatement":
+		test := &TestStatement{Token: token, Body: d.block("body")}
+		if name, ok := d.node("name").(*StringLiteral); ok {
+			test.Name = name
+		} else if d.err == nil {
+			d.err = fmt.Errorf("Field 'name' on '%s' node is not a string literal", d.kind)
+		}
+		node = test
+
+	case "ImportStatement":
+		stmt := &ImportStatement{Token: token, Name: d.identifier("name")}
+		if path, ok := d.node("path").(*StringLiteral); ok {
+			stmt.Path = path
+		} else if d.err == nil {
+			d.err = fmt.Errorf("Field 'path' on '%s' node is not a string literal", d.kind)
+		}
+		node = stmt
+
+	default:
+		return nil, fmt.Errorf("Unknown node type: '%s'", d.kind)
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	return node, nil
+}
@@ -20,6 +20,9 @@ func (v *VarStatement) statementNode() {}
 func (v *VarStatement) TokenLiteral() string {
 	return v.Token.Literal
 }
+func (v *VarStatement) Position() (int, int) {
+	return v.Token.Line, v.Token.Column
+}
 
 func (v *VarStatement) ToString(lvl int) string {
 	var out bytes.Buffer
@@ -48,6 +51,9 @@ func (v *ReturnStatement) statementNode() {}
 func (v *ReturnStatement) TokenLiteral() string {
 	return v.Token.Literal
 }
+func (v *ReturnStatement) Position() (int, int) {
+	return v.Token.Line, v.Token.Column
+}
 func (r *ReturnStatement) ToString(lvl int) string {
 	var out bytes.Buffer
 
@@ -77,6 +83,9 @@ func (v *ExpressionStatement) statementNode() {}
 func (v *ExpressionStatement) TokenLiteral() string {
 	return v.Token.Literal
 }
+func (v *ExpressionStatement) Position() (int, int) {
+	return v.Token.Line, v.Token.Column
+}
 func (e *ExpressionStatement) ToString(lvl int) string {
 	var out bytes.Buffer
 
@@ -96,6 +105,9 @@ func (b *BlockStatement) statementNode() {}
 func (b *BlockStatement) TokenLiteral() string {
 	return b.Token.Literal
 }
+func (b *BlockStatement) Position() (int, int) {
+	return b.Token.Line, b.Token.Column
+}
 func (b *BlockStatement) ToString(lvl int) string {
 	var buffer bytes.Buffer
 
@@ -126,6 +138,9 @@ func (f *FunctionStatement) statementNode() {}
 func (f *FunctionStatement) TokenLiteral() string {
 	return f.Token.Literal
 }
+func (f *FunctionStatement) Position() (int, int) {
+	return f.Token.Line, f.Token.Column
+}
 func (f *FunctionStatement) ToString(lvl int) string {
 	var buffer bytes.Buffer
 
@@ -141,3 +156,125 @@ func (f *FunctionStatement) ToString(lvl int) string {
 
 	return buffer.String()
 }
+
+// intentar { ... } capturar (e) { ... } finalmente { ... }
+// The catch block (with its identifier) and the finally block are optional, but at least
+// one of them is present.
+type TryStatement struct {
+	Body       *BlockStatement
+	Identifier *Identifier // bound to the caught exception
+	Catch      *BlockStatement
+	Finally    *BlockStatement
+	Token      tokens.Token
+}
+
+func (t *TryStatement) statementNode() {}
+func (t *TryStatement) TokenLiteral() string {
+	return t.Token.Literal
+}
+func (t *TryStatement) Position() (int, int) {
+	return t.Token.Line, t.Token.Column
+}
+func (t *TryStatement) ToString(lvl int) string {
+	var buffer bytes.Buffer
+
+	indent := strings.Repeat("  ", lvl)
+	buffer.WriteString(indent + "try statement:\n")
+	buffer.WriteString(indent + "  body:\n")
+	buffer.WriteString(t.Body.ToString(lvl + 2))
+
+	if t.Catch != nil {
+		buffer.WriteString(indent + "  catch:\n")
+		buffer.WriteString(t.Identifier.ToString(lvl + 2))
+		buffer.WriteString(t.Catch.ToString(lvl + 2))
+	}
+
+	if t.Finally != nil {
+		buffer.WriteString(indent + "  finally:\n")
+		buffer.WriteString(t.Finally.ToString(lvl + 2))
+	}
+
+	return buffer.String()
+}
+
+type ThrowStatement struct {
+	Value Expression
+	Token tokens.Token
+}
+
+func (t *ThrowStatement) statementNode() {}
+func (t *ThrowStatement) TokenLiteral() string {
+	return t.Token.Literal
+}
+func (t *ThrowStatement) Position() (int, int) {
+	return t.Token.Line, t.Token.Column
+}
+func (t *ThrowStatement) ToString(lvl int) string {
+	var out bytes.Buffer
+
+	indent := strings.Repeat("  ", lvl)
+	out.WriteString(indent + "throw statement:\n")
+	out.WriteString(indent + "  value: \n")
+
+	if t.Value != nil {
+		out.WriteString(t.Value.ToString(lvl + 2))
+	} else {
+		out.WriteString("nil")
+	}
+
+	return out.String()
+}
+
+// prueba "nombre" { ... }
+// Tests are skipped when running a program, and run by the test runner.
+type TestStatement struct {
+	Name  *StringLiteral
+	Body  *BlockStatement
+	Token tokens.Token
+}
+
+func (t *TestStatement) statementNode() {}
+func (t *TestStatement) TokenLiteral() string {
+	return t.Token.Literal
+}
+func (t *TestStatement) Position() (int, int) {
+	return t.Token.Line, t.Token.Column
+}
+func (t *TestStatement) ToString(lvl int) string {
+	var buffer bytes.Buffer
+
+	indent := strings.Repeat("  ", lvl)
+	buffer.WriteString(indent + "test statement:\n")
+	buffer.WriteString(t.Name.ToString(lvl + 1))
+	buffer.WriteString(indent + "  body:\n")
+	buffer.WriteString(t.Body.ToString(lvl + 2))
+
+	return buffer.String()
+}
+
+// importar "ruta" como nombre
+// The top-level bindings of the file are accessed through the name, like nombre.func()
+type ImportStatement struct {
+	Path  *StringLiteral
+	Name  *Identifier
+	Token tokens.Token
+}
+
+func (i *ImportStatement) statementNode() {}
+func (i *ImportStatement) TokenLiteral() string {
+	return i.Token.Literal
+}
+func (i *ImportStatement) Position() (int, int) {
+	return i.Token.Line, i.Token.Column
+}
+func (i *ImportStatement) ToString(lvl int) string {
+	var buffer bytes.Buffer
+
+	indent := strings.Repeat("  ", lvl)
+	buffer.WriteString(indent + "import statement:\n")
+	buffer.WriteString(i.Path.ToString(lvl + 1))
+	buffer.WriteString(indent + "  as:\n")
+	buffer.WriteString(i.Name.ToString(lvl + 2))
+
+	return buffer.String()
+}
@@ -0,0 +1,109 @@
+package ast
+
+// Walks the tree in depth-first order, calling fn with every node before its children.
+// When fn returns false, the children of the node are skipped. Missing nodes (like the
+// alternative of an if without "sino") are not visited.
+func Walk(node Node, fn func(Node) bool) {
+	if isNil(node) || !fn(node) {
+		return
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		for _, stmt := range node.Statements {
+			Walk(stmt, fn)
+		}
+
+	// -- Statements --
+	case *VarStatement:
+		Walk(node.Identifier, fn)
+		Walk(node.Value, fn)
+
+	case *ReturnStatement:
+		Walk(node.ReturnValue, fn)
+
+	case *ExpressionStatement:
+		Walk(node.Expression, fn)
+
+	case *BlockStatement:
+		for _, stmt := range node.Statements {
+			Walk(stmt, fn)
+		}
+
+	case *FunctionStatement:
+		Walk(node.Identifier, fn)
+		for _, param := range node.Parameters {
+			Walk(param, fn)
+		}
+		Walk(node.Body, fn)
+
+	case *TryStatement:
+		Walk(node.Body, fn)
+		Walk(node.Identifier, fn)
+		Walk(node.Catch, fn)
+		Walk(node.Finally, fn)
+
+	case *ThrowStatement:
+		Walk(node.Value, fn)
+
+	case *TestStatement:
+		Walk(node.Name, fn)
+		Walk(node.Body, fn)
+
+	case *ImportStatement:
+		Walk(node.Path, fn)
+		Walk(node.Name, fn)
+
+	// -- Expressions --
+	case *InterpolatedString:
+		for _, part := range node.Parts {
+			Walk(part, fn)
+		}
+
+	case *PrefixExpression:
+		Walk(node.Right, fn)
+
+	case *InfixExpression:
+		Walk(node.Left, fn)
+		Walk(node.Right, fn)
+
+	case *IfExpression:
+		Walk(node.Condition, fn)
+		Walk(node.Consequence, fn)
+		Walk(node.Alternative, fn)
+
+	case *AnonymousFunction:
+		for _, param := range node.Parameters {
+			Walk(param, fn)
+		}
+		Walk(node.Body, fn)
+
+	case *FunctionCall:
+		Walk(node.Identifier, fn)
+		for _, arg := range node.Arguments {
+			Walk(arg, fn)
+		}
+
+	case *ForLoop:
+		Walk(&node.Iterations, fn)
+		Walk(node.Body, fn)
+
+	case *MemberExpression:
+		Walk(node.Object, fn)
+		Walk(node.Member, fn)
+	}
+}
+
+// Reports if the node is nil, including the nil pointers stored on interfaces
+func isNil(node Node) bool {
+	switch node := node.(type) {
+	case nil:
+		return true
+	case *BlockStatement:
+		return node == nil
+	case *Identifier:
+		return node == nil
+	}
+
+	return false
+}
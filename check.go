@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/parser"
+	"github.com/sl2.0/repl"
+)
+
+// The "check" subcommand reports the lexical and syntax errors of programs, without
+// executing them. Every error is prefixed by the name of its file.
+// Usage: check [-keywords es] [file|-]...
+func check(args []string) int {
+	flags := newFlagSet("check")
+	keywords := addKeywordsFlag(flags)
+	flags.Parse(args)
+
+	keywordSet, ok := lookupKeywords(*keywords)
+	if !ok {
+		return exitUsage
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	status := repl.EXIT_OK
+	for _, path := range paths {
+		source, err := readInput(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading input file: "+err.Error())
+			status = max(status, repl.EXIT_FAILURE)
+			continue
+		}
+
+		p := parser.NewParserFromLexer(lexer.NewLexerWithKeywords(source, keywordSet))
+		p.ParseProgram()
+
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", displayName(path), msg)
+		}
+		if len(p.Errors()) != 0 {
+			status = repl.EXIT_SYNTAX_ERROR
+		}
+	}
+
+	return status
+}
+
+// name of an input on messages
+func displayName(path string) string {
+	if path == "" || path == "-" {
+		return "<stdin>"
+	}
+
+	return path
+}
@@ -0,0 +1,255 @@
+package coverage
+
+/*
+Coverage records which statements of a program are executed, and which branches of
+its "si" expressions are taken. Every "si" has two branches, the consequence and the
+alternative, even when it has no "sino" block (the alternative is then to skip it).
+
+The program is the one given to NewCoverage, and only its statements are counted, so
+the coverage of a program is recorded by evaluating it (or its statements) with the
+coverage as the tracer. Many evaluations can be recorded on the same coverage.
+
+The reports are an LCOV file, for tools like genhtml and editors, and the source of the
+program annotated with the executions of every line.
+*/
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sl2.0/ast"
+	"github.com/sl2.0/evaluator"
+)
+
+type position struct {
+	line   int
+	column int
+}
+
+// Executions of the branches of a "si" expression
+type Branch struct {
+	Line   int
+	Column int
+
+	Taken    int // executions of the consequence
+	NotTaken int // executions of the alternative (or skips of the consequence)
+}
+
+type Coverage struct {
+	evaluator.NopTracer
+
+	statements map[position]int // executions of every statement
+	branches   map[position]*Branch
+}
+
+// Creates the coverage of the program, with every statement and branch unexecuted
+func NewCoverage(program *ast.Program) *Coverage {
+	c := &Coverage{
+		statements: map[position]int{},
+		branches:   map[position]*Branch{},
+	}
+
+	ast.Walk(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Program:
+			c.addStatements(node.Statements)
+		case *ast.BlockStatement:
+			c.addStatements(node.Statements)
+		case *ast.IfExpression:
+			line, column := node.Position()
+			c.branches[position{line, column}] = &Branch{Line: line, Column: column}
+		}
+
+		return true
+	})
+
+	return c
+}
+
+func (c *Coverage) addStatements(statements []ast.Statement) {
+	for _, stmt := range statements {
+		line, column := stmt.Position()
+		c.statements[position{line, column}] = 0
+	}
+}
+
+func (c *Coverage) OnStatement(e *evaluator.Evaluator, stmt ast.Statement) {
+	line, column := stmt.Position()
+	if count, ok := c.statements[position{line, column}]; ok {
+		c.statements[position{line, column}] = count + 1
+	}
+}
+
+func (c *Coverage) OnBranch(e *evaluator.Evaluator, exp *ast.IfExpression, taken bool) {
+	line, column := exp.Position()
+	branch, ok := c.branches[position{line, column}]
+	if !ok {
+		return
+	}
+
+	if taken {
+		branch.Taken++
+	} else {
+		branch.NotTaken++
+	}
+}
+
+// Returns the executions of every line with statements. A line is executed as many
+// times as its most executed statement.
+func (c *Coverage) Lines() map[int]int {
+	lines := map[int]int{}
+	for pos, count := range c.statements {
+		lines[pos.line] = max(lines[pos.line], count)
+	}
+
+	return lines
+}
+
+// Returns the branches of every "si" expression, sorted by position
+func (c *Coverage) Branches() []Branch {
+	branches := make([]Branch, 0, len(c.branches))
+	for _, branch := range c.branches {
+		branches = append(branches, *branch)
+	}
+
+	sort.Slice(branches, func(i, j int) bool {
+		if branches[i].Line != branches[j].Line {
+			return branches[i].Line < branches[j].Line
+		}
+		return branches[i].Column < branches[j].Column
+	})
+
+	return branches
+}
+
+// Returns the number of executed lines and the number of lines with statements
+func (c *Coverage) LineSummary() (int, int) {
+	lines := c.Lines()
+
+	hit := 0
+	for _, count := range lines {
+		if count > 0 {
+			hit++
+		}
+	}
+
+	return hit, len(lines)
+}
+
+// Returns the number of taken branches and the total number of branches
+func (c *Coverage) BranchSummary() (int, int) {
+	hit := 0
+	for _, branch := range c.branches {
+		if branch.Taken > 0 {
+			hit++
+		}
+		if branch.NotTaken > 0 {
+			hit++
+		}
+	}
+
+	return hit, 2 * len(c.branches)
+}
+
+// Writes the coverage on the LCOV format, as the record of the given source file
+func (c *Coverage) WriteLCOV(w io.Writer, path string) error {
+	var out strings.Builder
+
+	out.WriteString("TN:\n")
+	fmt.Fprintf(&out, "SF:%s\n", path)
+
+	for n, branch := range c.Branches() {
+		evaluated := branch.Taken+branch.NotTaken > 0
+		fmt.Fprintf(&out, "BRDA:%d,%d,0,%s\n", branch.Line, n, branchCount(branch.Taken, evaluated))
+		fmt.Fprintf(&out, "BRDA:%d,%d,1,%s\n", branch.Line, n, branchCount(branch.NotTaken, evaluated))
+	}
+	hit, total := c.BranchSummary()
+	fmt.Fprintf(&out, "BRF:%d\nBRH:%d\n", total, hit)
+
+	lines := c.Lines()
+	for _, line := range sortedLines(lines) {
+		fmt.Fprintf(&out, "DA:%d,%d\n", line, lines[line])
+	}
+	hit, total = c.LineSummary()
+	fmt.Fprintf(&out, "LF:%d\nLH:%d\n", total, hit)
+
+	out.WriteString("end_of_record\n")
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// LCOV uses "-" for the branches of conditions never evaluated
+func branchCount(count int, evaluated bool) string {
+	if !evaluated {
+		return "-"
+	}
+
+	return fmt.Sprint(count)
+}
+
+// Writes the source with the executions of every line on the margin. Unexecuted lines
+// are marked with "#####", and lines with untaken branches are noted at the end.
+func (c *Coverage) WriteReport(w io.Writer, source string) error {
+	var out strings.Builder
+
+	lines := c.Lines()
+
+	untaken := map[int][]string{}
+	for _, branch := range c.Branches() {
+		if branch.Taken == 0 {
+			untaken[branch.Line] = append(untaken[branch.Line], "si")
+		}
+		if branch.NotTaken == 0 {
+			untaken[branch.Line] = append(untaken[branch.Line], "sino")
+		}
+	}
+
+	for n, text := range strings.Split(strings.TrimSuffix(source, "\n"), "\n") {
+		line := n + 1
+
+		count, executable := lines[line]
+		switch {
+		case !executable:
+			out.WriteString("       | ")
+		case count == 0:
+			out.WriteString("  #####| ")
+		default:
+			fmt.Fprintf(&out, "%7d| ", count)
+		}
+
+		out.WriteString(text)
+		if branches := untaken[line]; len(branches) > 0 {
+			fmt.Fprintf(&out, "  <- branch never taken: %s", strings.Join(branches, ", "))
+		}
+		out.WriteString("\n")
+	}
+
+	hit, total := c.LineSummary()
+	fmt.Fprintf(&out, "\nLines: %d/%d (%s)\n", hit, total, percentage(hit, total))
+	hit, total = c.BranchSummary()
+	fmt.Fprintf(&out, "Branches: %d/%d (%s)\n", hit, total, percentage(hit, total))
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func percentage(hit, total int) string {
+	if total == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f%%", float64(hit)*100/float64(total))
+}
+
+func sortedLines(lines map[int]int) []int {
+	sorted := make([]int, 0, len(lines))
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+	sort.Ints(sorted)
+
+	return sorted
+}
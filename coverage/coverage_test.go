@@ -0,0 +1,119 @@
+package coverage
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/objects"
+	"github.com/sl2.0/parser"
+)
+
+const program = `func signo(n) {
+    si (n < 0) {
+        retorna "negativo"
+    } sino {
+        retorna "positivo"
+    }
+}
+
+func nunca() {
+    retorna 1
+}
+
+si (false) { 1 }
+signo(3)
+signo(4)
+`
+
+func record(t *testing.T, src string) *Coverage {
+	p := parser.NewParser(src)
+	program := p.ParseProgram()
+	if p.HasErrors() {
+		t.Fatalf("cannot parse the program: %v", p.Errors())
+	}
+
+	c := NewCoverage(program)
+
+	ev := evaluator.NewFromProgram(program)
+	ev.SetTracer(c)
+	ev.EvalProgram(objects.NewStorage())
+
+	return c
+}
+
+func TestLinesAndBranches(t *testing.T) {
+	c := record(t, program)
+
+	expectedLines := map[int]int{1: 1, 2: 2, 3: 0, 5: 2, 9: 1, 10: 0, 13: 1, 14: 1, 15: 1}
+	if lines := c.Lines(); !reflect.DeepEqual(lines, expectedLines) {
+		t.Errorf("expected lines %v. Got %v", expectedLines, lines)
+	}
+
+	expectedBranches := []Branch{
+		{Line: 2, Column: 5, Taken: 0, NotTaken: 2},
+		{Line: 13, Column: 1, Taken: 0, NotTaken: 1},
+	}
+	if branches := c.Branches(); !reflect.DeepEqual(branches, expectedBranches) {
+		t.Errorf("expected branches %v. Got %v", expectedBranches, branches)
+	}
+
+	if hit, total := c.LineSummary(); hit != 7 || total != 9 {
+		t.Errorf("expected 7 of 9 lines. Got %d of %d", hit, total)
+	}
+	if hit, total := c.BranchSummary(); hit != 2 || total != 4 {
+		t.Errorf("expected 2 of 4 branches. Got %d of %d", hit, total)
+	}
+}
+
+func TestWriteLCOV(t *testing.T) {
+	var out bytes.Buffer
+	if err := record(t, "si (true) {\n    1\n}\nfunc f() {\n    si (true) { 2 }\n}\n").WriteLCOV(&out, "prog.sl"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `TN:
+SF:prog.sl
+BRDA:1,0,0,1
+BRDA:1,0,1,0
+BRDA:5,1,0,-
+BRDA:5,1,1,-
+BRF:4
+BRH:1
+DA:1,1
+DA:2,1
+DA:4,1
+DA:5,0
+LF:4
+LH:3
+end_of_record
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	var out bytes.Buffer
+	if err := record(t, program).WriteReport(&out, program); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	expected := map[int]string{
+		1:  "      2|     si (n < 0) {  <- branch never taken: si",
+		2:  "  #####|         retorna \"negativo\"",
+		3:  "       |     } sino {",
+		14: "      1| signo(4)",
+		16: "Lines: 7/9 (77.8%)",
+		17: "Branches: 2/4 (50.0%)",
+	}
+
+	for n, line := range expected {
+		if n >= len(lines) || lines[n] != line {
+			t.Errorf("expected the line %d to be %q. Got:\n%s", n, line, out.String())
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sl2.0/dap"
+	"github.com/sl2.0/repl"
+)
+
+// The "dap" subcommand serves the Debug Adapter Protocol over the standard input and
+// output, for editors like VS Code. The program is given by the "launch" request.
+// Usage: dap
+func serveDAP(args []string) int {
+	flags := newFlagSet("dap")
+	flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return repl.EXIT_FAILURE
+	}
+
+	return repl.EXIT_OK
+}
@@ -0,0 +1,348 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const program = `func factorial(n) {
+    si (n < 2) {
+        retorna 1
+    }
+    retorna factorial(n - 1) * n
+}
+
+var lista = argumentos()
+imprimir(factorial(3))
+`
+
+// In-process client, connected to a server through pipes
+type client struct {
+	t        *testing.T
+	w        io.Writer
+	messages chan map[string]any
+	pending  []map[string]any // received but not expected yet
+	seq      int
+	done     chan error
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{
+		t:        t,
+		w:        clientOut,
+		messages: make(chan map[string]any, 100),
+		done:     make(chan error, 1),
+	}
+
+	go func() {
+		c.done <- NewServer(serverIn, serverOut).Serve()
+		serverOut.Close()
+	}()
+
+	// the messages are read while the test waits, so the server never blocks writing
+	go func() {
+		r := bufio.NewReader(clientIn)
+		for {
+			content, err := readMessage(r)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+
+			var message map[string]any
+			json.Unmarshal(content, &message)
+			c.messages <- message
+		}
+	}()
+
+	t.Cleanup(func() { clientOut.Close() })
+
+	return c
+}
+
+func (c *client) send(command string, args any) int {
+	c.seq++
+	writeMessage(c.w, map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": args})
+	return c.seq
+}
+
+// Waits for the first message accepted by match. The rest are kept for later calls.
+func (c *client) next(match func(map[string]any) bool) map[string]any {
+	c.t.Helper()
+
+	for n, message := range c.pending {
+		if match(message) {
+			c.pending = append(c.pending[:n], c.pending[n+1:]...)
+			return message
+		}
+	}
+
+	for {
+		select {
+		case message, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("the server closed the connection")
+			}
+			if match(message) {
+				return message
+			}
+			c.pending = append(c.pending, message)
+		case <-time.After(5 * time.Second):
+			c.t.Fatalf("timeout waiting for a message")
+		}
+	}
+}
+
+func (c *client) event(name string) map[string]any {
+	c.t.Helper()
+
+	return c.next(func(m map[string]any) bool {
+		return m["type"] == "event" && m["event"] == name
+	})
+}
+
+// Sends a request and waits for its response, failing if it is not successful
+func (c *client) request(command string, args any) map[string]any {
+	c.t.Helper()
+
+	res := c.requestWithError(command, args)
+	if res["success"] != true {
+		c.t.Fatalf("%s: request failed: %v", command, res["message"])
+	}
+
+	body, _ := res["body"].(map[string]any)
+	return body
+}
+
+func (c *client) requestWithError(command string, args any) map[string]any {
+	c.t.Helper()
+
+	seq := c.send(command, args)
+	return c.next(func(m map[string]any) bool {
+		return m["type"] == "response" && m["request_seq"] == float64(seq)
+	})
+}
+
+func writeProgram(t *testing.T, src string) string {
+	path := filepath.Join(t.TempDir(), "programa.sl")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestDebugSession(t *testing.T) {
+	c := newClient(t)
+
+	capabilities := c.request("initialize", map[string]any{"adapterID": "sl"})
+	if capabilities["supportsConfigurationDoneRequest"] != true {
+		t.Errorf("expected support for configurationDone. Got %v", capabilities)
+	}
+
+	path := writeProgram(t, program)
+	c.request("launch", map[string]any{"program": path, "args": []string{"a", "b"}})
+	c.event("initialized")
+
+	// the empty line 7 has no statement to stop on
+	res := c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": path},
+		"breakpoints": []map[string]any{{"line": 3}, {"line": 7}},
+	})
+	breakpoints := res["breakpoints"].([]any)
+	if len(breakpoints) != 2 || breakpoints[0].(map[string]any)["verified"] != true ||
+		breakpoints[1].(map[string]any)["verified"] != false {
+		t.Errorf("expected only the breakpoint of line 3 to be verified. Got %v", breakpoints)
+	}
+	c.request("configurationDone", nil)
+
+	stopped := c.event("stopped")
+	if stopped["body"].(map[string]any)["reason"] != "breakpoint" {
+		t.Errorf("expected a stop on the breakpoint. Got %v", stopped)
+	}
+
+	// factorial(1) called by factorial(2), factorial(3) and the program
+	trace := c.request("stackTrace", map[string]any{"threadId": threadID})
+	frames := trace["stackFrames"].([]any)
+	names, lines := []any{}, []any{}
+	for _, frame := range frames {
+		names = append(names, frame.(map[string]any)["name"])
+		lines = append(lines, frame.(map[string]any)["line"])
+	}
+	expectedNames := []any{"factorial", "factorial", "factorial", "main"}
+	expectedLines := []any{float64(3), float64(5), float64(5), float64(9)}
+	if len(names) != 4 || names[0] != expectedNames[0] || names[3] != expectedNames[3] {
+		t.Errorf("expected frames %v. Got %v", expectedNames, names)
+	}
+	for n := range expectedLines {
+		if n < len(lines) && lines[n] != expectedLines[n] {
+			t.Errorf("expected lines %v. Got %v", expectedLines, lines)
+			break
+		}
+	}
+
+	// scopes of the frame of factorial(2)
+	frameID := frames[1].(map[string]any)["id"]
+	scopes := c.request("scopes", map[string]any{"frameId": frameID})["scopes"].([]any)
+	if len(scopes) != 3 {
+		t.Fatalf("expected 3 scopes. Got %v", scopes)
+	}
+
+	local := scopes[0].(map[string]any)["variablesReference"]
+	vars := c.request("variables", map[string]any{"variablesReference": local})["variables"].([]any)
+	if len(vars) != 1 || vars[0].(map[string]any)["value"] != "2" {
+		t.Errorf("expected n = 2. Got %v", vars)
+	}
+
+	global := scopes[2].(map[string]any)["variablesReference"]
+	vars = c.request("variables", map[string]any{"variablesReference": global})["variables"].([]any)
+	if len(vars) != 2 || vars[0].(map[string]any)["value"] != "func(n)" {
+		t.Errorf("expected the global factorial and lista. Got %v", vars)
+	}
+
+	// the elements of a list can be expanded
+	listRef := vars[1].(map[string]any)["variablesReference"]
+	elements := c.request("variables", map[string]any{"variablesReference": listRef})["variables"].([]any)
+	if len(elements) != 2 || elements[1].(map[string]any)["value"] != "b" {
+		t.Errorf("expected the arguments a and b. Got %v", elements)
+	}
+
+	// changing n on factorial(2) changes the result: (1 * 5) * 3
+	c.request("setVariable", map[string]any{"variablesReference": local, "name": "n", "value": "5"})
+	result := c.request("evaluate", map[string]any{"expression": "n * 10", "frameId": frameID})
+	if result["result"] != "50" {
+		t.Errorf("expected 50. Got %v", result)
+	}
+
+	if res := c.requestWithError("setVariable", map[string]any{"variablesReference": local, "name": "x", "value": "1"}); res["success"] != false {
+		t.Errorf("expected an error setting an undefined variable")
+	}
+
+	c.request("setBreakpoints", map[string]any{"source": map[string]any{"path": path}, "breakpoints": []any{}})
+	c.request("continue", map[string]any{"threadId": threadID})
+
+	output := c.event("output")["body"].(map[string]any)
+	if output["output"] != "15\n" {
+		t.Errorf("expected the output 15. Got %v", output)
+	}
+
+	exited := c.event("exited")["body"].(map[string]any)
+	if exited["exitCode"] != float64(0) {
+		t.Errorf("expected exit code 0. Got %v", exited)
+	}
+	c.event("terminated")
+
+	c.request("disconnect", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestStopOnEntryAndTerminate(t *testing.T) {
+	c := newClient(t)
+	c.request("initialize", nil)
+	c.request("configurationDone", nil)
+	c.request("launch", map[string]any{"program": writeProgram(t, program), "stopOnEntry": true})
+
+	if reason := c.event("stopped")["body"].(map[string]any)["reason"]; reason != "entry" {
+		t.Errorf("expected a stop on entry. Got %v", reason)
+	}
+
+	c.request("next", map[string]any{"threadId": threadID})
+	c.event("stopped")
+	trace := c.request("stackTrace", map[string]any{"threadId": threadID})
+	if line := trace["stackFrames"].([]any)[0].(map[string]any)["line"]; line != float64(8) {
+		t.Errorf("expected to stop on line 8. Got %v", line)
+	}
+
+	c.request("terminate", nil)
+	if exited := c.event("exited")["body"].(map[string]any); exited["exitCode"] != float64(0) {
+		t.Errorf("expected exit code 0. Got %v", exited)
+	}
+
+	if res := c.requestWithError("continue", nil); res["success"] != false {
+		t.Errorf("expected an error continuing a terminated program")
+	}
+}
+
+func TestBreakpointsOfOtherSources(t *testing.T) {
+	path := writeProgram(t, program)
+	module := filepath.Join(filepath.Dir(path), "util.sl")
+
+	c := newClient(t)
+	c.request("initialize", nil)
+
+	if res := c.requestWithError("setBreakpoints", map[string]any{"source": map[string]any{"path": path}}); res["success"] != false {
+		t.Errorf("expected an error setting breakpoints before the launch")
+	}
+
+	c.request("launch", map[string]any{"program": path})
+	c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": path},
+		"breakpoints": []map[string]any{{"line": 3}},
+	})
+
+	// the breakpoints of other files are not verified, and keep the ones of the program
+	res := c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": module},
+		"breakpoints": []map[string]any{{"line": 1}},
+	})
+	if breakpoints := res["breakpoints"].([]any); len(breakpoints) != 1 || breakpoints[0].(map[string]any)["verified"] != false {
+		t.Errorf("expected an unverified breakpoint. Got %v", breakpoints)
+	}
+
+	c.request("configurationDone", nil)
+	stopped := c.event("stopped")
+	if stopped["body"].(map[string]any)["reason"] != "breakpoint" {
+		t.Errorf("expected a stop on the breakpoint of the program. Got %v", stopped)
+	}
+
+	c.request("disconnect", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDisconnectWhileRunning(t *testing.T) {
+	// the long output keeps the program running while the client disconnects, before
+	// reaching the breakpoints
+	src := "importar \"texto\" como t\nimprimir(t.replicar(\"a\", 1000000))\nvar x = 1\nvar y = 2\n"
+
+	c := newClient(t)
+	c.request("initialize", nil)
+	path := writeProgram(t, src)
+	c.request("launch", map[string]any{"program": path})
+	c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": path},
+		"breakpoints": []map[string]any{{"line": 3}, {"line": 4}},
+	})
+	c.request("configurationDone", nil)
+
+	c.request("disconnect", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLaunchErrors(t *testing.T) {
+	c := newClient(t)
+	c.request("initialize", nil)
+
+	if res := c.requestWithError("launch", map[string]any{"program": "no_existe.sl"}); res["success"] != false {
+		t.Errorf("expected an error launching a missing file")
+	}
+	if res := c.requestWithError("launch", map[string]any{"program": writeProgram(t, "var x = ;")}); res["success"] != false {
+		t.Errorf("expected an error launching a program with syntax errors")
+	}
+	if res := c.requestWithError("attach", nil); res["success"] != false {
+		t.Errorf("expected an error on unsupported requests")
+	}
+}
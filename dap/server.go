@@ -0,0 +1,627 @@
+package dap
+
+/*
+Server implements the Debug Adapter Protocol on top of the debugger package, so editors
+like VS Code can debug programs. A server debugs a single program, launched with the
+"launch" request once the client sends "configurationDone".
+
+The program runs on its own goroutine, which blocks on every stop until the client
+resumes it. Its output is sent to the client with "output" events.
+
+Every stop has a single thread. Frames are numbered from 1 (the program itself), and the
+references to variables are valid until the program is resumed.
+*/
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sl2.0/ast"
+	"github.com/sl2.0/debugger"
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/objects"
+	"github.com/sl2.0/parser"
+	"github.com/sl2.0/repl"
+	"github.com/sl2.0/tokens"
+)
+
+// id of the only thread of the programs
+const threadID = 1
+
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	writeMu sync.Mutex // serializes the messages
+	seq     int
+
+	debugger *debugger.Debugger
+	program  *evaluator.Evaluator // nil until launched
+	path     string
+	lines    map[int]bool // lines with statements, the only ones where breakpoints stop
+	keywords *tokens.KeywordSet
+
+	configured bool
+	started    bool
+	done       chan struct{} // closed when the program ends
+
+	// state of the stopped program, shared with its goroutine
+	mu     sync.Mutex
+	stop   *debugger.Stop // nil while running
+	refs   []any          // referenced scopes and values, the reference is the index + 1
+	resume chan debugger.Action
+	quit   bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	s := &Server{
+		in:     bufio.NewReader(in),
+		out:    out,
+		resume: make(chan debugger.Action, 1),
+		done:   make(chan struct{}),
+	}
+	s.debugger = debugger.NewDebugger(s.stopped)
+
+	return s
+}
+
+// Handles the requests until the client disconnects or the input ends
+func (s *Server) Serve() error {
+	for {
+		content, err := readMessage(s.in)
+		if err == io.EOF {
+			s.end()
+			return nil
+		}
+		if err != nil {
+			s.end()
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			s.end()
+			return fmt.Errorf("invalid message: %w", err)
+		}
+
+		if !s.handle(&req) {
+			return nil
+		}
+	}
+}
+
+// Handles a request. Returns false when the session ends.
+func (s *Server) handle(req *request) bool {
+	var body any
+	var err error
+
+	switch req.Command {
+	case "initialize":
+		body = capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsSetVariable:              true,
+			SupportsTerminateRequest:         true,
+		}
+		s.respond(req, body, nil)
+		return true
+
+	case "launch":
+		// the configuration, like the breakpoints, is checked against the launched program
+		err = s.launch(req.Arguments)
+		s.respond(req, nil, err)
+		if err == nil {
+			s.sendEvent("initialized", nil)
+		}
+		return true
+
+	case "setBreakpoints":
+		body, err = s.setBreakpoints(req.Arguments)
+	case "configurationDone":
+		s.configured = true
+		s.start()
+	case "threads":
+		body = map[string]any{"threads": []thread{{ID: threadID, Name: "main"}}}
+	case "stackTrace":
+		body, err = s.stackTrace()
+	case "scopes":
+		body, err = s.scopes(req.Arguments)
+	case "variables":
+		body, err = s.variables(req.Arguments)
+	case "setVariable":
+		body, err = s.setVariable(req.Arguments)
+	case "evaluate":
+		body, err = s.evaluate(req.Arguments)
+	case "continue":
+		body = map[string]any{"allThreadsContinued": true}
+		err = s.resumeWith(debugger.Continue)
+	case "next":
+		err = s.resumeWith(debugger.StepOver)
+	case "stepIn":
+		err = s.resumeWith(debugger.StepIn)
+	case "stepOut":
+		err = s.resumeWith(debugger.StepOut)
+	case "pause":
+		s.debugger.Pause()
+	case "terminate":
+		s.terminate()
+	case "disconnect":
+		s.end()
+		s.respond(req, nil, nil)
+		return false
+	default:
+		err = fmt.Errorf("unsupported request '%s'", req.Command)
+	}
+
+	s.respond(req, body, err)
+	return true
+}
+
+func (s *Server) launch(arguments json.RawMessage) error {
+	var args launchArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return err
+	}
+
+	if s.program != nil {
+		return fmt.Errorf("a program was already launched")
+	}
+
+	keywords := tokens.Spanish
+	if args.Keywords != "" {
+		set, ok := tokens.LookupKeywordSet(args.Keywords)
+		if !ok {
+			return fmt.Errorf("invalid keyword set: %s", args.Keywords)
+		}
+		keywords = set
+	}
+
+	src, err := os.ReadFile(args.Program)
+	if err != nil {
+		return err
+	}
+
+	l := lexer.NewLexerWithKeywords(string(src), keywords)
+	p := parser.NewParserFromLexer(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return fmt.Errorf("%s", strings.Join(p.Errors(), "\n"))
+	}
+
+	s.path, _ = filepath.Abs(args.Program)
+	s.lines = statementLines(program)
+	s.keywords = l.Keywords()
+	s.program = evaluator.NewFromProgram(program)
+	s.program.SetArgs(args.Args)
+	s.program.SetPath(s.path)
+	s.program.SetModules(evaluator.NewModules(keywords))
+	s.program.SetOutput(&outputWriter{server: s, category: "stdout"})
+
+	if args.StopOnEntry {
+		s.debugger.StopOnEntry()
+	}
+
+	s.start()
+	return nil
+}
+
+// Starts the program once it is launched and the client has sent its configuration
+func (s *Server) start() {
+	if s.program == nil || !s.configured || s.started {
+		return
+	}
+	s.started = true
+
+	go s.run()
+}
+
+func (s *Server) run() {
+	defer close(s.done)
+
+	result := s.debugger.Run(s.program, objects.NewStorage())
+
+	code := repl.EXIT_OK
+	switch result := result.(type) {
+	case *objects.ExitObject:
+		code = int(result.Code)
+	case *objects.ErrorObject:
+		s.mu.Lock()
+		quit := s.quit
+		s.mu.Unlock()
+
+		if !quit {
+			message := result.Inspect() + "\n"
+			if len(result.Trace) > 0 {
+				message += result.StackTrace() + "\n"
+			}
+			s.sendEvent("output", map[string]any{"category": "stderr", "output": message})
+			code = repl.EXIT_RUNTIME_ERROR
+		}
+	}
+
+	s.sendEvent("exited", map[string]any{"exitCode": code})
+	s.sendEvent("terminated", nil)
+}
+
+// Handler of the debugger, called on the goroutine of the program
+func (s *Server) stopped(stop *debugger.Stop) debugger.Action {
+	s.mu.Lock()
+	if s.quit {
+		// terminated before stopping, so nobody would resume it
+		s.mu.Unlock()
+		return debugger.Quit
+	}
+	s.stop = stop
+	s.refs = nil
+	s.mu.Unlock()
+
+	s.sendEvent("stopped", map[string]any{
+		"reason":            stop.Reason,
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+
+	return <-s.resume
+}
+
+func (s *Server) resumeWith(action debugger.Action) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop == nil {
+		return fmt.Errorf("the program is not stopped")
+	}
+
+	s.stop = nil
+	s.resume <- action
+
+	return nil
+}
+
+// Stops the program, if it is running
+func (s *Server) terminate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quit = true
+	if s.stop != nil {
+		s.stop = nil
+		s.resume <- debugger.Quit
+	} else {
+		s.debugger.Quit()
+	}
+}
+
+// Stops the program and waits for it to end
+func (s *Server) end() {
+	if !s.started {
+		return
+	}
+
+	s.terminate()
+	<-s.done
+}
+
+func (s *Server) setBreakpoints(arguments json.RawMessage) (any, error) {
+	var args setBreakpointsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	if s.program == nil {
+		return nil, fmt.Errorf("the program is not launched")
+	}
+
+	// a server debugs a single file, so the breakpoints of other sources, like the
+	// imported modules, are not set
+	path, _ := filepath.Abs(args.Source.Path)
+	if path != s.path {
+		breakpoints := []breakpoint{}
+		for _, b := range args.Breakpoints {
+			breakpoints = append(breakpoints, breakpoint{Line: b.Line, Message: "not in the debugged program"})
+		}
+		return map[string]any{"breakpoints": breakpoints}, nil
+	}
+
+	// the breakpoints of the request replace all
+	for _, line := range s.debugger.Breakpoints() {
+		s.debugger.ClearBreakpoint(line)
+	}
+
+	breakpoints := []breakpoint{}
+	for _, b := range args.Breakpoints {
+		if !s.lines[b.Line] {
+			breakpoints = append(breakpoints, breakpoint{Line: b.Line, Message: "no statement on this line"})
+			continue
+		}
+
+		s.debugger.SetBreakpoint(b.Line)
+		breakpoints = append(breakpoints, breakpoint{Verified: true, Line: b.Line})
+	}
+
+	return map[string]any{"breakpoints": breakpoints}, nil
+}
+
+// Returns the lines where a statement starts, including the ones inside blocks
+func statementLines(program *ast.Program) map[int]bool {
+	lines := map[int]bool{}
+	ast.Walk(program, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.Program, *ast.BlockStatement:
+		case ast.Statement:
+			line, _ := node.Position()
+			lines[line] = true
+		}
+		return true
+	})
+
+	return lines
+}
+
+// Returns the current stop. Must be called with the lock held.
+func (s *Server) current() (*debugger.Stop, error) {
+	if s.stop == nil {
+		return nil, fmt.Errorf("the program is not stopped")
+	}
+
+	return s.stop, nil
+}
+
+// Returns the frame of a frame id. Must be called with the lock held.
+func (s *Server) frame(id int) (*evaluator.Frame, error) {
+	stop, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+
+	// without a frame the expressions are evaluated on the current one
+	if id == 0 {
+		id = len(stop.Frames)
+	}
+	if id < 1 || id > len(stop.Frames) {
+		return nil, fmt.Errorf("invalid frame %d", id)
+	}
+
+	return &stop.Frames[id-1], nil
+}
+
+func (s *Server) stackTrace() (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stop, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+
+	frames := []stackFrame{}
+	for n := len(stop.Frames) - 1; n >= 0; n-- {
+		frame := stop.Frames[n]
+
+		// the functions of modules are on their own files
+		path := s.path
+		if frame.File != "" {
+			path = frame.File
+		}
+
+		frames = append(frames, stackFrame{
+			ID:     n + 1,
+			Name:   frame.Function,
+			Source: source{Name: filepath.Base(path), Path: path},
+			Line:   frame.Line,
+			Column: frame.Column,
+		})
+	}
+
+	return map[string]any{"stackFrames": frames, "totalFrames": len(frames)}, nil
+}
+
+// Every scope of the chain of the frame is a DAP scope, from the innermost one
+func (s *Server) scopes(arguments json.RawMessage) (any, error) {
+	var args scopesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	frame, err := s.frame(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := []scope{}
+	for env, n := frame.Env, 0; env != nil; env, n = env.Outer(), n+1 {
+		name := "Local"
+		if env.Outer() == nil {
+			name = "Global"
+		} else if n > 0 {
+			name = fmt.Sprintf("Enclosing %d", n)
+		}
+
+		scopes = append(scopes, scope{Name: name, VariablesReference: s.reference(env)})
+	}
+
+	return map[string]any{"scopes": scopes}, nil
+}
+
+// Returns a new reference to a scope or value. Must be called with the lock held.
+func (s *Server) reference(value any) int {
+	s.refs = append(s.refs, value)
+	return len(s.refs)
+}
+
+func (s *Server) lookup(ref int) (any, error) {
+	if ref < 1 || ref > len(s.refs) {
+		return nil, fmt.Errorf("invalid variables reference %d", ref)
+	}
+
+	return s.refs[ref-1], nil
+}
+
+func (s *Server) variables(arguments json.RawMessage) (any, error) {
+	var args variablesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.current(); err != nil {
+		return nil, err
+	}
+
+	value, err := s.lookup(args.VariablesReference)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := []variable{}
+	switch value := value.(type) {
+	case *objects.Storage:
+		for _, name := range value.Identifiers() {
+			obj, _ := value.Get(name)
+			vars = append(vars, s.variable(name, obj))
+		}
+	case *objects.Array:
+		for n, element := range value.Elements {
+			vars = append(vars, s.variable(fmt.Sprintf("[%d]", n), element))
+		}
+	case *objects.Map:
+		for _, pair := range value.Pairs {
+			vars = append(vars, s.variable(pair.Key.Inspect(), pair.Value))
+		}
+	}
+
+	return map[string]any{"variables": vars}, nil
+}
+
+// Describes a value. Lists and maps can be expanded. Must be called with the lock held.
+func (s *Server) variable(name string, value objects.Object) variable {
+	v := variable{Name: name, Value: debugger.Describe(value)}
+	if value == nil {
+		return v
+	}
+
+	v.Type = string(value.Type())
+	switch value := value.(type) {
+	case *objects.Array:
+		if len(value.Elements) > 0 {
+			v.VariablesReference = s.reference(value)
+		}
+	case *objects.Map:
+		if len(value.Pairs) > 0 {
+			v.VariablesReference = s.reference(value)
+		}
+	}
+
+	return v
+}
+
+func (s *Server) setVariable(arguments json.RawMessage) (any, error) {
+	var args setVariableArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.current(); err != nil {
+		return nil, err
+	}
+
+	ref, err := s.lookup(args.VariablesReference)
+	if err != nil {
+		return nil, err
+	}
+
+	env, ok := ref.(*objects.Storage)
+	if !ok {
+		return nil, fmt.Errorf("only the variables of a scope can be changed")
+	}
+
+	value, err := s.debugger.Assign(env, args.Name, args.Value, s.keywords)
+	if err != nil {
+		return nil, err
+	}
+
+	v := s.variable(args.Name, value)
+	return map[string]any{"value": v.Value, "type": v.Type, "variablesReference": v.VariablesReference}, nil
+}
+
+func (s *Server) evaluate(arguments json.RawMessage) (any, error) {
+	var args evaluateArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	frame, err := s.frame(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := s.debugger.Eval(frame.Env, args.Expression, s.keywords)
+	if err != nil {
+		return nil, err
+	}
+
+	v := s.variable("", value)
+	return map[string]any{"result": v.Value, "type": v.Type, "variablesReference": v.VariablesReference}, nil
+}
+
+func (s *Server) respond(req *request, body any, err error) {
+	res := response{
+		Type:       "response",
+		RequestSeq: req.Seq,
+		Success:    err == nil,
+		Command:    req.Command,
+		Body:       body,
+	}
+	if err != nil {
+		res.Message = err.Error()
+		res.Body = nil
+	}
+
+	s.send(func(seq int) any {
+		res.Seq = seq
+		return res
+	})
+}
+
+func (s *Server) sendEvent(name string, body any) {
+	s.send(func(seq int) any {
+		return event{Seq: seq, Type: "event", Event: name, Body: body}
+	})
+}
+
+// Writes a message built with the next sequence number
+func (s *Server) send(message func(seq int) any) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.seq++
+	writeMessage(s.out, message(s.seq))
+}
+
+// Sends the output of the program to the client
+type outputWriter struct {
+	server   *Server
+	category string
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.server.sendEvent("output", map[string]any{"category": w.category, "output": string(p)})
+	return len(p), nil
+}
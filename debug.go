@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sl2.0/debugger"
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/objects"
+	"github.com/sl2.0/parser"
+	"github.com/sl2.0/repl"
+	"github.com/sl2.0/tokens"
+)
+
+// The "debug" subcommand runs a program under the debugger, reading its commands from
+// the standard input. Without breakpoints the program stops before its first statement.
+// Usage: debug [-keywords es] [-allow-env *] [-b line]... file [-- args...]
+func debug(args []string) int {
+	flags := newFlagSet("debug")
+	keywords := addKeywordsFlag(flags)
+	allowEnv := addAllowEnvFlag(flags)
+	breakpoints := lineList{}
+	flags.Var(&breakpoints, "b", "Line with a breakpoint (can be repeated)")
+	flags.Parse(args)
+
+	// the standard input is used for the commands of the debugger
+	path := flags.Arg(0)
+	if path == "" || path == "-" {
+		flags.Usage()
+		return exitUsage
+	}
+
+	keywordSet, ok := lookupKeywords(*keywords)
+	if !ok {
+		return exitUsage
+	}
+
+	scriptArgs := []string{}
+	if flags.NArg() > 1 {
+		scriptArgs = flags.Args()[1:]
+	}
+	if len(scriptArgs) > 0 && scriptArgs[0] == "--" {
+		scriptArgs = scriptArgs[1:]
+	}
+
+	src, err := readInput(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening input file: "+err.Error())
+		return repl.EXIT_FAILURE
+	}
+
+	l := lexer.NewLexerWithKeywords(src, keywordSet)
+	p := parser.NewParserFromLexer(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintln(os.Stderr, msg)
+		}
+		return repl.EXIT_SYNTAX_ERROR
+	}
+
+	session := &debugSession{
+		lines:    strings.Split(src, "\n"),
+		keywords: l.Keywords(),
+		in:       bufio.NewScanner(os.Stdin),
+		out:      os.Stdout,
+	}
+	session.debugger = debugger.NewDebugger(session.stopped)
+	if len(breakpoints) == 0 {
+		session.debugger.StopOnEntry()
+	}
+	for _, line := range breakpoints {
+		session.debugger.SetBreakpoint(line)
+	}
+
+	ev := evaluator.NewFromProgram(program)
+	ev.SetArgs(scriptArgs)
+	ev.SetAllowedEnv(allowedEnv(*allowEnv))
+	ev.SetPath(path)
+	ev.SetModules(evaluator.NewModules(keywordSet))
+
+	result := session.debugger.Run(ev, objects.NewStorage())
+
+	switch result := result.(type) {
+	case *objects.ExitObject:
+		fmt.Fprintf(session.out, "Program exited with status %d\n", result.Code)
+		return int(result.Code)
+	case *objects.ErrorObject:
+		if session.quit {
+			fmt.Fprintln(session.out, "Debugging stopped")
+			return repl.EXIT_OK
+		}
+		fmt.Fprintln(os.Stderr, result.Inspect())
+		if len(result.Trace) > 0 {
+			fmt.Fprintln(os.Stderr, result.StackTrace())
+		}
+		return repl.EXIT_RUNTIME_ERROR
+	case nil:
+		fmt.Fprintln(session.out, "Program finished")
+	default:
+		fmt.Fprintf(session.out, "Program finished: %s\n", result.Inspect())
+	}
+
+	return repl.EXIT_OK
+}
+
+// Lines of the -b flag
+type lineList []int
+
+func (l *lineList) String() string {
+	return fmt.Sprint(*l)
+}
+
+func (l *lineList) Set(value string) error {
+	line, err := strconv.Atoi(value)
+	if err != nil || line < 1 {
+		return fmt.Errorf("invalid line: %s", value)
+	}
+	*l = append(*l, line)
+
+	return nil
+}
+
+const debugHelp = `Commands:
+  c, continue        run until the next breakpoint
+  s, step            stop on the next line, entering into functions
+  n, next            stop on the next line of the current function
+  o, out             stop after the current function returns
+  b, break <line>    set a breakpoint (without line, list them)
+  d, delete <line>   remove a breakpoint
+  bt, backtrace      print the call stack
+  f, frame <n>       select a frame of the call stack for print, vars and set
+  p, print <expr>    evaluate an expression on the selected frame
+  v, vars            print the variables visible from the selected frame
+  set <name> = <expr>
+                     change the value of a variable
+  l, list            print the source around the current line
+  q, quit            stop the program
+  h, help            show this help`
+
+// Commands of the user for a program stopped by the debugger
+type debugSession struct {
+	debugger *debugger.Debugger
+	lines    []string // source of the program
+	keywords *tokens.KeywordSet
+
+	in  *bufio.Scanner
+	out io.Writer
+
+	stop  *debugger.Stop
+	frame int  // selected frame
+	quit  bool // the user stopped the program
+}
+
+// Handles a stop of the program, reading commands until one resumes it. When the input
+// ends the program runs until the end, ignoring the breakpoints.
+func (s *debugSession) stopped(stop *debugger.Stop) debugger.Action {
+	s.stop = stop
+	s.frame = len(stop.Frames) - 1
+
+	if stop.Returned != nil {
+		fmt.Fprintf(s.out, "%s returned %s\n", stop.Function, debugger.Describe(stop.Returned))
+	}
+
+	function := stop.Frames[s.frame].Function
+	switch stop.Reason {
+	case debugger.BREAKPOINT:
+		fmt.Fprintf(s.out, "Breakpoint at line %d (%s)\n", stop.Line, function)
+	default:
+		fmt.Fprintf(s.out, "Stopped at line %d (%s)\n", stop.Line, function)
+	}
+	s.printLine(stop.Line)
+
+	for {
+		fmt.Fprint(s.out, "(debug) ")
+		if !s.in.Scan() {
+			fmt.Fprintln(s.out)
+			for _, line := range s.debugger.Breakpoints() {
+				s.debugger.ClearBreakpoint(line)
+			}
+			return debugger.Continue
+		}
+
+		command, arg, _ := strings.Cut(strings.TrimSpace(s.in.Text()), " ")
+		arg = strings.TrimSpace(arg)
+
+		switch command {
+		case "c", "continue":
+			return debugger.Continue
+		case "s", "step":
+			return debugger.StepIn
+		case "n", "next":
+			return debugger.StepOver
+		case "o", "out":
+			return debugger.StepOut
+		case "q", "quit":
+			s.quit = true
+			return debugger.Quit
+		case "b", "break":
+			s.setBreakpoint(arg)
+		case "d", "delete":
+			s.deleteBreakpoint(arg)
+		case "bt", "backtrace":
+			s.printFrames()
+		case "f", "frame":
+			s.selectFrame(arg)
+		case "p", "print":
+			s.print(arg)
+		case "v", "vars":
+			s.printVariables()
+		case "set":
+			s.set(arg)
+		case "l", "list":
+			for line := max(stop.Line-5, 1); line <= min(stop.Line+5, len(s.lines)); line++ {
+				s.printLine(line)
+			}
+		case "h", "help":
+			fmt.Fprintln(s.out, debugHelp)
+		case "":
+		default:
+			fmt.Fprintf(s.out, "Unknown command '%s'. Type 'h' for help\n", command)
+		}
+	}
+}
+
+// Prints a line of the source, marking the current one
+func (s *debugSession) printLine(line int) {
+	if line < 1 || line > len(s.lines) {
+		return
+	}
+
+	mark := " "
+	if line == s.stop.Line {
+		mark = ">"
+	}
+	fmt.Fprintf(s.out, "%s %4d | %s\n", mark, line, s.lines[line-1])
+}
+
+func (s *debugSession) setBreakpoint(arg string) {
+	if arg == "" {
+		fmt.Fprintf(s.out, "Breakpoints: %v\n", s.debugger.Breakpoints())
+		return
+	}
+
+	line, err := strconv.Atoi(arg)
+	if err != nil || line < 1 || line > len(s.lines) {
+		fmt.Fprintf(s.out, "Invalid line: %s\n", arg)
+		return
+	}
+
+	s.debugger.SetBreakpoint(line)
+	fmt.Fprintf(s.out, "Breakpoint set at line %d\n", line)
+}
+
+func (s *debugSession) deleteBreakpoint(arg string) {
+	line, err := strconv.Atoi(arg)
+	if err != nil || !s.debugger.ClearBreakpoint(line) {
+		fmt.Fprintf(s.out, "No breakpoint at line %s\n", arg)
+		return
+	}
+
+	fmt.Fprintf(s.out, "Breakpoint deleted at line %d\n", line)
+}
+
+// Prints the call stack from the innermost frame, marking the selected one
+func (s *debugSession) printFrames() {
+	for n := len(s.stop.Frames) - 1; n >= 0; n-- {
+		mark := " "
+		if n == s.frame {
+			mark = ">"
+		}
+
+		frame := s.stop.Frames[n]
+		fmt.Fprintf(s.out, "%s #%d %s (%s)\n", mark, n, frame.Function, location(frame))
+	}
+}
+
+// Line of the frame, with the file of the functions of modules like "util.sl, line 2"
+func location(frame evaluator.Frame) string {
+	if frame.File == "" {
+		return fmt.Sprintf("line %d", frame.Line)
+	}
+
+	return fmt.Sprintf("%s, line %d", filepath.Base(frame.File), frame.Line)
+}
+
+func (s *debugSession) selectFrame(arg string) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 || n >= len(s.stop.Frames) {
+		fmt.Fprintf(s.out, "Invalid frame: %s\n", arg)
+		return
+	}
+
+	s.frame = n
+	frame := s.stop.Frames[n]
+	fmt.Fprintf(s.out, "#%d %s (%s)\n", n, frame.Function, location(frame))
+}
+
+func (s *debugSession) env() *objects.Storage {
+	return s.stop.Frames[s.frame].Env
+}
+
+func (s *debugSession) print(arg string) {
+	value, err := s.debugger.Eval(s.env(), arg, s.keywords)
+	if err != nil {
+		fmt.Fprintln(s.out, "Error: "+err.Error())
+		return
+	}
+
+	fmt.Fprintln(s.out, debugger.Describe(value))
+}
+
+// Prints the variables of every scope, from the innermost one
+func (s *debugSession) printVariables() {
+	scope := -1
+	for _, v := range debugger.Variables(s.env()) {
+		if v.Scope != scope {
+			scope = v.Scope
+			fmt.Fprintf(s.out, "scope %d:\n", scope)
+		}
+
+		fmt.Fprintf(s.out, "  %s = %s\n", v.Name, debugger.Describe(v.Value))
+	}
+}
+
+func (s *debugSession) set(arg string) {
+	name, expr, ok := strings.Cut(arg, "=")
+	name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+	if !ok || name == "" || expr == "" {
+		fmt.Fprintln(s.out, "Usage: set <name> = <expr>")
+		return
+	}
+
+	value, err := s.debugger.Assign(s.env(), name, expr, s.keywords)
+	if err != nil {
+		fmt.Fprintln(s.out, "Error: "+err.Error())
+		return
+	}
+
+	fmt.Fprintf(s.out, "%s = %s\n", name, debugger.Describe(value))
+}
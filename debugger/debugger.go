@@ -0,0 +1,336 @@
+package debugger
+
+/*
+Debugger runs programs stopping before the statements on the lines with breakpoints, or
+step by step. Every stop is reported to a handler, which runs on the goroutine of the
+evaluation (so the program is paused until it returns) and chooses how to resume it.
+
+While stopped, the handler can inspect the call stack with the frames of the stop, and
+evaluate expressions or modify variables on the scope of any frame. Those evaluations
+end with the program, or after a max time, as they could never end.
+
+Breakpoints can be changed, and the program paused or stopped, from other goroutines
+while the program runs.
+
+Many statements can share a line (like "si x { y }"). Only the first one of a line is a
+location where the program stops, so a line is stepped over with a single step.
+*/
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sl2.0/ast"
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/objects"
+	"github.com/sl2.0/parser"
+	"github.com/sl2.0/tokens"
+)
+
+// Action chosen by the handler to resume the program
+type Action int
+
+const (
+	Continue Action = iota // run until the next breakpoint
+	StepIn                 // stop on the next line, entering into the called functions
+	StepOver               // stop on the next line of the current function or its callers
+	StepOut                // stop after the current function returns
+	Quit                   // stop the evaluation
+)
+
+// Max time of the evaluations of Eval and Assign by default
+const DefaultEvalTimeout = 5 * time.Second
+
+// Reasons of a stop
+const (
+	ENTRY      = "entry"
+	BREAKPOINT = "breakpoint"
+	STEP       = "step"
+	PAUSE      = "pause"
+)
+
+// A pause of the program before evaluating a statement
+type Stop struct {
+	Reason string
+	Line   int
+	Column int
+
+	// call stack, starting from the program itself. The last frame runs the statement.
+	Frames []evaluator.Frame
+
+	// value returned by the last function which ended since the previous stop (nil if
+	// none ended)
+	Returned objects.Object
+	Function string // name of the function which returned
+}
+
+type Handler func(stop *Stop) Action
+
+type Debugger struct {
+	handler     Handler
+	stopOnEntry bool
+	evalTimeout time.Duration
+
+	// shared with other goroutines
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	paused      bool
+	ctx         context.Context // of the running program
+	cancel      context.CancelFunc
+
+	// how the program was resumed, and the depth of the stack at that moment
+	action Action
+	depth  int
+
+	// location of the last statement, to recognize the ones sharing a line
+	line   int
+	column int
+	last   int // depth of the stack
+
+	returned objects.Object
+	function string
+}
+
+func NewDebugger(handler Handler) *Debugger {
+	return &Debugger{
+		breakpoints: make(map[int]bool),
+		handler:     handler,
+		evalTimeout: DefaultEvalTimeout,
+	}
+}
+
+// Stops the program before its first statement
+func (d *Debugger) StopOnEntry() {
+	d.stopOnEntry = true
+}
+
+// Sets the max time of the evaluations of Eval and Assign
+func (d *Debugger) SetEvalTimeout(timeout time.Duration) {
+	d.evalTimeout = timeout
+}
+
+func (d *Debugger) SetBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.breakpoints[line] = true
+}
+
+// Returns false if the line had no breakpoint
+func (d *Debugger) ClearBreakpoint(line int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ok := d.breakpoints[line]
+	delete(d.breakpoints, line)
+
+	return ok
+}
+
+// Returns the sorted lines with breakpoints
+func (d *Debugger) Breakpoints() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+
+	return lines
+}
+
+// Stops the running program before its next statement
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.paused = true
+}
+
+// Ends the running program before its next statement, like the Quit action
+func (d *Debugger) Quit() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.cancel != nil {
+		d.cancel()
+	}
+}
+
+// Evaluates the program of the evaluator on the given scope, stopping on the
+// breakpoints. If the handler quits, the evaluation ends with an error.
+func (d *Debugger) Run(ev *evaluator.Evaluator, env *objects.Storage) objects.Object {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d.mu.Lock()
+	d.ctx, d.cancel = ctx, cancel
+	d.mu.Unlock()
+
+	d.action = Continue
+	if d.stopOnEntry {
+		d.action = StepIn
+	}
+
+	ev.SetContext(ctx)
+	ev.SetTracer(d)
+
+	return ev.EvalProgram(env)
+}
+
+func (d *Debugger) OnStatement(e *evaluator.Evaluator, stmt ast.Statement) {
+	line, column := stmt.Position()
+	depth := e.Depth()
+
+	// statements after the first one of a line are nested or follow it
+	sameLine := line == d.line && depth == d.last && column > d.column
+	d.line, d.column, d.last = line, column, depth
+	if sameLine {
+		return
+	}
+
+	d.mu.Lock()
+	paused, breakpoint := d.paused, d.breakpoints[line]
+	d.paused = false
+	d.mu.Unlock()
+
+	reason := ""
+	switch {
+	case paused:
+		reason = PAUSE
+	case d.action == StepIn && d.stopOnEntry:
+		reason = ENTRY
+		d.stopOnEntry = false
+	case d.action == StepIn,
+		d.action == StepOver && depth <= d.depth,
+		d.action == StepOut && depth < d.depth:
+		reason = STEP
+	case breakpoint:
+		reason = BREAKPOINT
+	default:
+		return
+	}
+
+	stop := &Stop{
+		Reason:   reason,
+		Line:     line,
+		Column:   column,
+		Frames:   e.Frames(),
+		Returned: d.returned,
+		Function: d.function,
+	}
+	d.returned, d.function = nil, ""
+
+	d.action = d.handler(stop)
+	d.depth = depth
+	if d.action == Quit {
+		d.Quit()
+	}
+}
+
+func (d *Debugger) OnCall(*evaluator.Evaluator, *evaluator.Frame) {}
+
+func (d *Debugger) OnReturn(e *evaluator.Evaluator, frame *evaluator.Frame, result objects.Object) {
+	d.returned, d.function = result, frame.Function
+}
+
+// A variable visible from a frame. Scope 0 is the scope of the frame, and the next ones
+// its enclosing scopes.
+type Variable struct {
+	Name  string
+	Value objects.Object
+	Scope int
+}
+
+// Returns the variables visible from the scope, including the ones shadowed by inner
+// scopes
+func Variables(env *objects.Storage) []Variable {
+	vars := []Variable{}
+	for scope := 0; env != nil; scope++ {
+		for _, name := range env.Identifiers() {
+			value, _ := env.Get(name)
+			vars = append(vars, Variable{Name: name, Value: value, Scope: scope})
+		}
+		env = env.Outer()
+	}
+
+	return vars
+}
+
+// Short representation of a value, without the bodies of the functions
+func Describe(value objects.Object) string {
+	switch value := value.(type) {
+	case nil:
+		return "(no value)"
+	case *objects.FunctionObject:
+		params := []string{}
+		for _, param := range value.Parameters {
+			params = append(params, param.Value)
+		}
+		return "func(" + strings.Join(params, ", ") + ")"
+	}
+
+	return value.Inspect()
+}
+
+// Evaluates an expression (or any statement) on the scope. The evaluation is not
+// traced, so it does not stop on breakpoints. It is stopped with the program (see
+// Quit), or when it takes longer than the max time.
+func (d *Debugger) Eval(env *objects.Storage, src string, keywords *tokens.KeywordSet) (objects.Object, error) {
+	p := parser.NewParserFromLexer(lexer.NewLexerWithKeywords(src, keywords))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, fmt.Errorf("%s", strings.Join(p.Errors(), "\n"))
+	}
+
+	d.mu.Lock()
+	parent := d.ctx
+	d.mu.Unlock()
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithTimeout(parent, d.evalTimeout)
+	defer cancel()
+
+	ev := evaluator.NewFromProgram(program)
+	ev.SetContext(ctx)
+	result := ev.EvalProgram(env)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("the evaluation did not end after %s", d.evalTimeout)
+	}
+	if ev.HasErrors() {
+		return nil, fmt.Errorf("%s", strings.Join(ev.Errors(), "\n"))
+	}
+	if err, ok := result.(*objects.ErrorObject); ok {
+		return nil, fmt.Errorf("%s", err.Inspect())
+	}
+	if result == nil {
+		return nil, fmt.Errorf("the expression has no value")
+	}
+
+	return result, nil
+}
+
+// Evaluates the expression and assigns its value to an existing variable of the scope
+// (or of its enclosing scopes)
+func (d *Debugger) Assign(env *objects.Storage, name, src string, keywords *tokens.KeywordSet) (objects.Object, error) {
+	if _, ok := env.Get(name); !ok {
+		return nil, fmt.Errorf("undefined variable '%s'", name)
+	}
+
+	value, err := d.Eval(env, src, keywords)
+	if err != nil {
+		return nil, err
+	}
+	env.Assign(name, value)
+
+	return value, nil
+}
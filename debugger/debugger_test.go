@@ -0,0 +1,264 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sl2.0/ast"
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/objects"
+	"github.com/sl2.0/tokens"
+)
+
+const factorial = `func factorial(n) {
+    si (n < 2) {
+        retorna 1
+    }
+    retorna n * factorial(n - 1)
+}
+
+var x = factorial(3)
+var y = x + 1`
+
+// Runs the source answering the stops with the given actions (Continue when they run
+// out). Returns the stops as "reason line function" and the result.
+func debug(t *testing.T, src string, d *Debugger, actions ...Action) ([]string, objects.Object) {
+	stops := []string{}
+	d.handler = func(stop *Stop) Action {
+		frame := stop.Frames[len(stop.Frames)-1]
+		stops = append(stops, fmt.Sprintf("%s %d %s", stop.Reason, stop.Line, frame.Function))
+
+		if len(actions) == 0 {
+			return Continue
+		}
+		action := actions[0]
+		actions = actions[1:]
+		return action
+	}
+
+	ev := evaluator.NewFromInput(src)
+	if ev == nil {
+		t.Fatalf("cannot parse the program")
+	}
+
+	return stops, d.Run(ev, objects.NewStorage())
+}
+
+func TestStepping(t *testing.T) {
+	testCases := []struct {
+		name     string
+		actions  []Action
+		expected []string
+	}{
+		{
+			name:     "step in",
+			actions:  []Action{StepIn, StepIn, StepIn, StepIn, StepIn, StepIn},
+			expected: []string{"entry 1 main", "step 8 main", "step 2 factorial", "step 5 factorial", "step 2 factorial", "step 5 factorial", "step 2 factorial"},
+		},
+		{
+			name:     "step over",
+			actions:  []Action{StepOver, StepOver},
+			expected: []string{"entry 1 main", "step 8 main", "step 9 main"},
+		},
+		{
+			name:     "step out",
+			actions:  []Action{StepIn, StepIn, StepIn, StepIn, StepOut},
+			expected: []string{"entry 1 main", "step 8 main", "step 2 factorial", "step 5 factorial", "step 2 factorial", "step 9 main"},
+		},
+	}
+
+	for _, tc := range testCases {
+		d := NewDebugger(nil)
+		d.StopOnEntry()
+
+		stops, result := debug(t, factorial, d, tc.actions...)
+		if !reflect.DeepEqual(stops, tc.expected) {
+			t.Errorf("%s: expected stops %v. Got %v", tc.name, tc.expected, stops)
+		}
+		testInteger(t, result, 7)
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	d := NewDebugger(nil)
+	d.SetBreakpoint(5)
+	d.SetBreakpoint(9)
+
+	stops, _ := debug(t, factorial, d)
+	expected := []string{"breakpoint 5 factorial", "breakpoint 5 factorial", "breakpoint 9 main"}
+	if !reflect.DeepEqual(stops, expected) {
+		t.Errorf("expected stops %v. Got %v", expected, stops)
+	}
+
+	if !d.ClearBreakpoint(5) || d.ClearBreakpoint(5) {
+		t.Errorf("expected to clear the breakpoint only once")
+	}
+	if lines := d.Breakpoints(); !reflect.DeepEqual(lines, []int{9}) {
+		t.Errorf("expected breakpoints [9]. Got %v", lines)
+	}
+
+	// statements sharing a line stop only once, but every iteration of a loop stops
+	d = NewDebugger(nil)
+	d.SetBreakpoint(2)
+	stops, _ = debug(t, "var x = 0\nrepetir 2 { si (true) { x } }\nx", d)
+	if len(stops) != 2 {
+		t.Errorf("expected 2 stops on the loop. Got %v", stops)
+	}
+}
+
+func TestModules(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "util.sl"), []byte("func f(n) {\n    retorna n * 2\n}\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stops := []string{}
+	d := NewDebugger(func(stop *Stop) Action {
+		frame := stop.Frames[len(stop.Frames)-1]
+		stops = append(stops, fmt.Sprintf("%s %d %s", stop.Reason, stop.Line, frame.Function))
+		return StepIn
+	})
+	d.SetBreakpoint(2)
+
+	ev := evaluator.NewFromInput("importar \"util.sl\" como u\nvar x = u.f(1)\nx")
+	ev.SetPath(filepath.Join(dir, "main.sl"))
+
+	// the lines of the module are not lines of the program, so the debugger never stops
+	// inside its functions
+	result := d.Run(ev, objects.NewStorage())
+	expected := []string{"breakpoint 2 main", "step 3 main"}
+	if !reflect.DeepEqual(stops, expected) {
+		t.Errorf("expected stops %v. Got %v", expected, stops)
+	}
+	testInteger(t, result, 2)
+}
+
+func TestQuit(t *testing.T) {
+	d := NewDebugger(nil)
+	d.StopOnEntry()
+
+	stops, result := debug(t, factorial, d, Quit)
+	if len(stops) != 1 {
+		t.Errorf("expected a single stop. Got %v", stops)
+	}
+	if _, ok := result.(*objects.ErrorObject); !ok {
+		t.Errorf("expected an error after quitting. Got %v", result)
+	}
+}
+
+func TestInspectAndModify(t *testing.T) {
+	d := NewDebugger(nil)
+	d.SetBreakpoint(3)
+
+	var vars []Variable
+	d.handler = func(stop *Stop) Action {
+		env := stop.Frames[len(stop.Frames)-1].Env
+		vars = Variables(env)
+
+		value, err := d.Eval(env, "n * 10", tokens.Spanish)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else {
+			testInteger(t, value, 10)
+		}
+
+		// the variable of the caller is found on the outer scope
+		if _, err := d.Assign(env, "x", "5", tokens.Spanish); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err := d.Assign(env, "indefinida", "5", tokens.Spanish); err == nil {
+			t.Errorf("expected an error assigning an undefined variable")
+		}
+		if _, err := d.Eval(env, "n +", tokens.Spanish); err == nil {
+			t.Errorf("expected a syntax error")
+		}
+
+		return Continue
+	}
+
+	ev := evaluator.NewFromInput("var x = 1\nfunc f(n) {\n    retorna n + x\n}\nf(1)")
+	result := d.Run(ev, objects.NewStorage())
+	testInteger(t, result, 6)
+
+	names := []string{}
+	for _, v := range vars {
+		names = append(names, fmt.Sprintf("%d:%s", v.Scope, v.Name))
+	}
+	expected := []string{"0:n", "1:f", "1:x"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected variables %v. Got %v", expected, names)
+	}
+}
+
+func TestEvalLimits(t *testing.T) {
+	endless := "repetir 1000000000 { 1 }"
+
+	// evaluations which take longer than the max time
+	d := NewDebugger(nil)
+	d.StopOnEntry()
+	d.SetEvalTimeout(20 * time.Millisecond)
+
+	var err error
+	d.handler = func(stop *Stop) Action {
+		_, err = d.Eval(stop.Frames[0].Env, endless, tokens.Spanish)
+		return Continue
+	}
+	d.Run(evaluator.NewFromInput("1"), objects.NewStorage())
+
+	if err == nil || !strings.Contains(err.Error(), "did not end") {
+		t.Errorf("expected a timeout. Got %v", err)
+	}
+
+	// evaluations stopped with the program, from other goroutine
+	d = NewDebugger(nil)
+	d.SetBreakpoint(2)
+
+	d.handler = func(stop *Stop) Action {
+		time.AfterFunc(20*time.Millisecond, d.Quit)
+		_, err = d.Assign(stop.Frames[0].Env, "x", endless, tokens.Spanish)
+		return Continue
+	}
+	d.Run(evaluator.NewFromInput("var x = 1\nx"), objects.NewStorage())
+
+	if err == nil || !strings.Contains(err.Error(), "Evaluation stopped") {
+		t.Errorf("expected the evaluation to stop with the program. Got %v", err)
+	}
+}
+
+func testInteger(t *testing.T, obj objects.Object, expected int64) {
+	t.Helper()
+
+	integer, ok := obj.(*objects.Integer)
+	if !ok {
+		t.Errorf("expected an integer. Got %v", obj)
+		return
+	}
+	if integer.Value != expected {
+		t.Errorf("expected %d. Got %d", expected, integer.Value)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	f := &objects.FunctionObject{Parameters: []*ast.Identifier{{Value: "a"}, {Value: "b"}}}
+
+	testCases := []struct {
+		value    objects.Object
+		expected string
+	}{
+		{value: nil, expected: "(no value)"},
+		{value: &objects.Integer{Value: 3}, expected: "3"},
+		{value: f, expected: "func(a, b)"},
+	}
+
+	for _, tc := range testCases {
+		if described := Describe(tc.value); described != tc.expected {
+			t.Errorf("expected %q. Got %q", tc.expected, described)
+		}
+	}
+}
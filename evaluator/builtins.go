@@ -0,0 +1,275 @@
+package evaluator
+
+import (
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sl2.0/objects"
+)
+
+// Builtin functions receive the evaluator which is running the call, so they can
+// access its state.
+type builtinFn func(e *Evaluator, args ...objects.Object) objects.Object
+
+var builtins = map[string]builtinFn{
+	"longitud":   builtinLength,
+	"argumentos": builtinArgs,
+	"entorno":    builtinEnv,
+	"salir":      builtinExit,
+	"imprimir":   builtinPrint,
+
+	"afirmar":       builtinAssert,
+	"afirmar_igual": builtinAssertEqual,
+}
+
+// Returns the sorted names of every builtin function
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Returns the builtin function with the given name bound to the evaluator. User
+// definitions always shadow builtins.
+func (e *Evaluator) lookupBuiltin(name string) (*objects.BuiltinFunction, bool) {
+	fn, ok := builtins[name]
+	if !ok {
+		return nil, false
+	}
+
+	return &objects.BuiltinFunction{
+		Name: name,
+		Fn: func(args ...objects.Object) objects.Object {
+			return fn(e, args...)
+		},
+	}, true
+}
+
+// longitud(cadena) returns the number of characters (not bytes) of a string, and
+// longitud(lista) the number of elements of an array
+func builtinLength(e *Evaluator, args ...objects.Object) objects.Object {
+	if len(args) != 1 {
+		return objects.NewError("longitud: expected 1 argument. Got %d", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *objects.String:
+		return &objects.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+	case *objects.Array:
+		return &objects.Integer{Value: int64(len(arg.Elements))}
+	}
+
+	return objects.NewError("longitud: argument not supported: %s", typeName(args[0]))
+}
+
+// argumentos() returns the arguments of the program as an array of strings, and
+// argumentos(n) only the argument at the index n (starting from 0)
+func builtinArgs(e *Evaluator, args ...objects.Object) objects.Object {
+	switch len(args) {
+	case 0:
+		elements := make([]objects.Object, 0, len(e.args))
+		for _, arg := range e.args {
+			elements = append(elements, &objects.String{Value: arg})
+		}
+
+		return e.allocate(&objects.Array{Elements: elements})
+
+	case 1:
+		index, ok := args[0].(*objects.Integer)
+		if !ok {
+			return objects.NewError("argumentos: expected an integer index. Got %s", typeName(args[0]))
+		}
+
+		if index.Value < 0 || index.Value >= int64(len(e.args)) {
+			return objects.NewError("argumentos: index %d out of range. The program has %d arguments",
+				index.Value, len(e.args))
+		}
+
+		return &objects.String{Value: e.args[index.Value]}
+	}
+
+	return objects.NewError("argumentos: expected 0 or 1 arguments. Got %d", len(args))
+}
+
+// entorno(nombre) returns the value of an environment variable, or an empty string if
+// it is not defined. Variables outside the allowed ones are an error.
+func builtinEnv(e *Evaluator, args ...objects.Object) objects.Object {
+	if len(args) != 1 {
+		return objects.NewError("entorno: expected 1 argument. Got %d", len(args))
+	}
+
+	name, ok := args[0].(*objects.String)
+	if !ok {
+		return objects.NewError("entorno: expected a string. Got %s", typeName(args[0]))
+	}
+
+	if e.allowedEnv != nil && !slices.Contains(e.allowedEnv, name.Value) {
+		return objects.NewError("entorno: access to the variable '%s' is not allowed", name.Value)
+	}
+
+	return e.allocate(&objects.String{Value: os.Getenv(name.Value)})
+}
+
+// salir(código) ends the program with the given exit status (0 when omitted). The
+// evaluation is unwound without running anything else.
+func builtinExit(e *Evaluator, args ...objects.Object) objects.Object {
+	if len(args) == 0 {
+		return &objects.ExitObject{Code: 0}
+	}
+
+	if len(args) != 1 {
+		return objects.NewError("salir: expected 0 or 1 arguments. Got %d", len(args))
+	}
+
+	code, ok := args[0].(*objects.Integer)
+	if !ok {
+		return objects.NewError("salir: expected an integer status. Got %s", typeName(args[0]))
+	}
+
+	// statuses are a single byte for the operating system
+	if code.Value < 0 || code.Value > 255 {
+		return objects.NewError("salir: the status must be between 0 and 255. Got %d", code.Value)
+	}
+
+	return &objects.ExitObject{Code: code.Value}
+}
+
+// imprimir(valores...) writes the values separated by spaces, followed by a line break
+func builtinPrint(e *Evaluator, args ...objects.Object) objects.Object {
+	values := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == nil {
+			return objects.NewError("imprimir: cannot print an expression without value")
+		}
+		values = append(values, arg.Inspect())
+	}
+
+	line := strings.Join(values, " ") + "\n"
+	if err := e.useOutput(len(line)); err != nil {
+		return err
+	}
+
+	io.WriteString(e.output, line)
+
+	return nil
+}
+
+// afirmar(condición, mensaje) fails with the message (optional) when the condition is
+// false. Failed assertions cannot be caught.
+func builtinAssert(e *Evaluator, args ...objects.Object) objects.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return objects.NewError("afirmar: expected 1 or 2 arguments. Got %d", len(args))
+	}
+
+	condition, ok := args[0].(*objects.Boolean)
+	if !ok {
+		return objects.NewError("afirmar: expected a boolean condition. Got %s", typeName(args[0]))
+	}
+
+	if condition.Value {
+		return nil
+	}
+
+	if len(args) == 2 {
+		if args[1] == nil {
+			return objects.NewError("afirmar: the message has no value")
+		}
+		return objects.NewErrorOfKind(objects.ASSERT_ERROR, "%s", args[1].Inspect())
+	}
+
+	return objects.NewErrorOfKind(objects.ASSERT_ERROR, "Assertion failed")
+}
+
+// afirmar_igual(obtenido, esperado) fails when the values are not equal. Lists and
+// maps are compared by their elements.
+func builtinAssertEqual(e *Evaluator, args ...objects.Object) objects.Object {
+	if len(args) != 2 {
+		return objects.NewError("afirmar_igual: expected 2 arguments. Got %d", len(args))
+	}
+
+	if equalObjects(args[0], args[1]) {
+		return nil
+	}
+
+	return objects.NewErrorOfKind(objects.ASSERT_ERROR, "Expected %s. Got %s", assertionValue(args[1]), assertionValue(args[0]))
+}
+
+func equalObjects(a, b objects.Object) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *objects.Array:
+		b := b.(*objects.Array)
+		if len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for n := range a.Elements {
+			if !equalObjects(a.Elements[n], b.Elements[n]) {
+				return false
+			}
+		}
+		return true
+
+	case *objects.Map:
+		b := b.(*objects.Map)
+		if len(a.Pairs) != len(b.Pairs) {
+			return false
+		}
+		// the order of the pairs does not matter
+		for _, pair := range a.Pairs {
+			found := false
+			for _, other := range b.Pairs {
+				if equalObjects(pair.Key, other.Key) {
+					found = equalObjects(pair.Value, other.Value)
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+
+	case *objects.FunctionObject, *objects.BuiltinFunction:
+		return a == b
+	}
+
+	return a.Inspect() == b.Inspect()
+}
+
+// Representation of a value on the messages of the assertions. Strings are quoted, so
+// they are not confused with other values.
+func assertionValue(obj objects.Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "no value"
+	case *objects.String:
+		return `"` + obj.Value + `"`
+	}
+
+	return obj.Inspect()
+}
+
+// type of an argument for error messages. Arguments can be nil (functions without
+// returned values).
+func typeName(obj objects.Object) objects.ObjectType {
+	if obj == nil {
+		return objects.NULL_OBJ
+	}
+
+	return obj.Type()
+}
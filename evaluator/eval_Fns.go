@@ -1,6 +1,8 @@
 package evaluator
 
 import (
+	"strings"
+
 	"github.com/sl2.0/ast"
 	"github.com/sl2.0/objects"
 )
@@ -16,23 +18,67 @@ func (e *Evaluator) evalPrefix(exp *ast.PrefixExpression, env *objects.Storage)
 	return objects.NewError("Prefix operation not supported: %s", exp.Operator)
 }
 
+// Both operands are evaluated (only once) before the operation
 func (e *Evaluator) evalInfix(exp *ast.InfixExpression, env *objects.Storage) objects.Object {
 	evalLeft := e.eval(exp.Left, env)
+	if isInterruption(evalLeft) {
+		return evalLeft
+	}
+
+	evalRight := e.eval(exp.Right, env)
+	if isInterruption(evalRight) {
+		return evalRight
+	}
 
-	switch evalLeft.Type() {
-	case objects.INTEGER_OBJ:
-		return e.evalArithmeticOperations(exp, env)
-	case objects.BOOL_OBJ:
-		return e.evalBooleanExpression(exp, env)
-	case objects.STRING_OBJ:
-		return e.evalStringExpression(exp, env)
+	if evalLeft == nil || evalRight == nil {
+		return objects.NewError("Cannot operate with an expression without value: %s", exp.ToString(0))
+	}
+
+	switch left := evalLeft.(type) {
+	case *objects.Integer:
+		return evalArithmeticOperations(exp.Operator, left, evalRight)
+	case *objects.Boolean:
+		return evalBooleanExpression(exp.Operator, left, evalRight)
+	case *objects.String:
+		result := evalStringExpression(exp.Operator, left, evalRight)
+		if exp.Operator == "+" {
+			return e.allocate(result)
+		}
+		return result
 	}
 
 	return objects.NewError("Not supported infix operation: %s", exp.Operator)
 }
 
+// Every part of the string is converted using the same representation as Inspect()
+func (e *Evaluator) evalInterpolatedString(exp *ast.InterpolatedString, env *objects.Storage) objects.Object {
+	var out strings.Builder
+
+	for _, part := range exp.Parts {
+		value := e.eval(part, env)
+		if value == nil {
+			return objects.NewError("Cannot interpolate an expression without value: %s", part.ToString(0))
+		}
+
+		if isInterruption(value) {
+			return value
+		}
+
+		out.WriteString(value.Inspect())
+	}
+
+	return e.allocate(&objects.String{Value: out.String()})
+}
+
 func (e *Evaluator) evalBangOperator(exp *ast.PrefixExpression, env *objects.Storage) objects.Object {
 	value := e.eval(exp.Right, env)
+	if isInterruption(value) {
+		return value
+	}
+
+	if value == nil {
+		return objects.NewError("Expected boolean expression for '!' operator. \n\tGot no value")
+	}
 
 	if value.Type() != objects.BOOL_OBJ {
 		return objects.NewError(
@@ -40,8 +86,7 @@ func (e *Evaluator) evalBangOperator(exp *ast.PrefixExpression, env *objects.Sto
 			value.Inspect())
 	}
 
-	// we can compare object references because we have static true and false
-	if value == true_obj {
+	if value.(*objects.Boolean).Value {
 		return false_obj
 	}
 
@@ -50,6 +95,13 @@ func (e *Evaluator) evalBangOperator(exp *ast.PrefixExpression, env *objects.Sto
 
 func (e *Evaluator) evalMinusPrefix(exp *ast.PrefixExpression, env *objects.Storage) objects.Object {
 	value := e.eval(exp.Right, env)
+	if isInterruption(value) {
+		return value
+	}
+
+	if value == nil {
+		return objects.NewError("Expected integer expression for '-' operator. \n\tGot no value")
+	}
 
 	if value.Type() != objects.INTEGER_OBJ {
 		return objects.NewError(
@@ -62,11 +114,7 @@ func (e *Evaluator) evalMinusPrefix(exp *ast.PrefixExpression, env *objects.Stor
 	return &objects.Integer{Value: -res.Value}
 }
 
-func (e *Evaluator) evalBooleanExpression(exp *ast.InfixExpression, env *objects.Storage) objects.Object {
-	left := e.eval(exp.Left, env).(*objects.Boolean)
-
-	evalRight := e.eval(exp.Right, env)
-
+func evalBooleanExpression(operator string, left *objects.Boolean, evalRight objects.Object) objects.Object {
 	if evalRight.Type() != objects.BOOL_OBJ {
 		return objects.NewError(
 			"Expected right value to be a boolean.\n\tGot: %v",
@@ -75,23 +123,19 @@ func (e *Evaluator) evalBooleanExpression(exp *ast.InfixExpression, env *objects
 
 	right := evalRight.(*objects.Boolean)
 
-	switch exp.Operator {
+	switch operator {
 	case "==":
-		return &objects.Boolean{Value: left.Value == right.Value}
+		return selectBoolObject(left.Value == right.Value)
 	case "!=":
-		return &objects.Boolean{Value: left.Value != right.Value}
+		return selectBoolObject(left.Value != right.Value)
 	}
 
 	return objects.NewError(
 		"Not supported operator: %s",
-		exp.Operator)
+		operator)
 }
 
-func (e *Evaluator) evalStringExpression(exp *ast.InfixExpression, env *objects.Storage) objects.Object {
-	left := e.eval(exp.Left, env).(*objects.String)
-
-	evalRight := e.eval(exp.Right, env)
-
+func evalStringExpression(operator string, left *objects.String, evalRight objects.Object) objects.Object {
 	if evalRight.Type() != objects.STRING_OBJ {
 		return objects.NewError(
 			"Expected right value to be a String.\n\tGot: %v",
@@ -100,7 +144,7 @@ func (e *Evaluator) evalStringExpression(exp *ast.InfixExpression, env *objects.
 
 	right := evalRight.(*objects.String)
 
-	switch exp.Operator {
+	switch operator {
 	case "==":
 		return &objects.Boolean{Value: left.Value == right.Value}
 	case "!=":
@@ -111,23 +155,19 @@ func (e *Evaluator) evalStringExpression(exp *ast.InfixExpression, env *objects.
 
 	return objects.NewError(
 		"Not supported operator: %s",
-		exp.Operator)
+		operator)
 }
 
-func (e *Evaluator) evalArithmeticOperations(exp *ast.InfixExpression, env *objects.Storage) objects.Object {
-	left := e.eval(exp.Left, env).(*objects.Integer)
-
-	evalRight := e.eval(exp.Right, env)
-
+func evalArithmeticOperations(operator string, left *objects.Integer, evalRight objects.Object) objects.Object {
 	if evalRight.Type() != objects.INTEGER_OBJ {
 		return objects.NewError(
 			"Expected right value of '%s' to be an integer. \n\tGot: %v",
-			exp.Operator, evalRight.Inspect())
+			operator, evalRight.Inspect())
 	}
 
 	right := evalRight.(*objects.Integer)
 
-	switch exp.Operator {
+	switch operator {
 	case "+":
 		return &objects.Integer{Value: left.Value + right.Value}
 	case "-":
@@ -135,6 +175,9 @@ func (e *Evaluator) evalArithmeticOperations(exp *ast.InfixExpression, env *obje
 	case "*":
 		return &objects.Integer{Value: left.Value * right.Value}
 	case "/":
+		if right.Value == 0 {
+			return objects.NewError("Division by zero")
+		}
 		return &objects.Integer{Value: left.Value / right.Value}
 	case ">":
 		return selectBoolObject(left.Value > right.Value)
@@ -148,13 +191,28 @@ func (e *Evaluator) evalArithmeticOperations(exp *ast.InfixExpression, env *obje
 
 	return objects.NewError(
 		"Not supported operator: %s",
-		exp.Operator,
+		operator,
 	)
 }
 
 func (e *Evaluator) evalIfExpression(exp *ast.IfExpression, env *objects.Storage) objects.Object {
 	condition := e.eval(exp.Condition, env)
 
+	// errors are reported as part of the message of the condition
+	if condition != nil && condition.Type() == objects.EXIT_OBJ {
+		return condition
+	}
+
+	if err, ok := condition.(*objects.ErrorObject); ok {
+		wrapped := objects.NewErrorOfKind(err.Kind, "Expected boolean expression for 'if' condition.\n\t%v", err.Inspect())
+		wrapped.(*objects.ErrorObject).Trace = err.Trace
+		return wrapped
+	}
+
+	if condition == nil {
+		return objects.NewError("Expected boolean expression for 'if' condition.\n\tGot no value")
+	}
+
 	if condition.Type() != objects.BOOL_OBJ {
 		return objects.NewError(
 			"Expected boolean expression for 'if' condition.\n\t%v",
@@ -162,7 +220,10 @@ func (e *Evaluator) evalIfExpression(exp *ast.IfExpression, env *objects.Storage
 		)
 	}
 
-	if condition == true_obj {
+	taken := condition.(*objects.Boolean).Value
+	e.traceBranch(exp, taken)
+
+	if taken {
 		return e.eval(exp.Consequence, env)
 	}
 
@@ -174,24 +235,69 @@ func (e *Evaluator) evalIfExpression(exp *ast.IfExpression, env *objects.Storage
 }
 
 func (e *Evaluator) evalFunctionCall(fun *ast.FunctionCall, env *objects.Storage) objects.Object {
-	f, ok := e.eval(fun.Identifier, env).(*objects.FunctionObject)
-	if !ok {
-		return objects.NewError("Function '%s' not found", fun.Identifier.ToString(0))
+	callee := e.eval(fun.Identifier, env)
+	if isInterruption(callee) {
+		return callee
 	}
 
-	// check argument list size
-	if len(fun.Arguments) != len(f.Parameters) {
-		return objects.NewError("Number of Arguments mismatch with number of Parameters")
+	switch callee := callee.(type) {
+	case *objects.FunctionObject:
+		// check argument list size (before evaluating them)
+		if len(fun.Arguments) != len(callee.Parameters) {
+			return objects.NewError("Number of Arguments mismatch with number of Parameters")
+		}
+	case *objects.BuiltinFunction:
+	default:
+		return objects.NewError("Function '%s' not found", fun.Identifier.ToString(0))
 	}
 
 	// eval every argument
 	args := e.evalExpressions(fun.Arguments, env)
-	if len(args) == 1 && isError(args[0]) {
+	if len(args) == 1 && isInterruption(args[0]) {
 		return args[0]
 	}
 
-	// Create a local scope (with maximum recurssion level)
-	localEnv, err := objects.NewEnclosedStorage(env)
+	e.setPosition(fun.Identifier)
+
+	return e.callFunction(functionName(fun.Identifier), callee, env, args...)
+}
+
+// Calls a function (defined by the user or builtin) with already evaluated arguments.
+// User functions are evaluated on a new scope enclosed by env.
+func (e *Evaluator) CallFunction(callee objects.Object, env *objects.Storage, args ...objects.Object) objects.Object {
+	name := anonymousFunction
+	if builtin, ok := callee.(*objects.BuiltinFunction); ok {
+		name = builtin.Name
+	}
+
+	return e.callFunction(name, callee, env, args...)
+}
+
+func (e *Evaluator) callFunction(name string, callee objects.Object, env *objects.Storage, args ...objects.Object) objects.Object {
+	var f *objects.FunctionObject
+
+	switch callee := callee.(type) {
+	case *objects.FunctionObject:
+		f = callee
+	case *objects.BuiltinFunction:
+		return callee.Fn(args...)
+	default:
+		return objects.NewError("Cannot call a value of type %s", typeName(callee))
+	}
+
+	if len(args) != len(f.Parameters) {
+		return objects.NewError("Number of Arguments mismatch with number of Parameters")
+	}
+
+	// Create a local scope (with maximum recurssion level). The functions of modules
+	// see their module instead of the scope of the caller.
+	var localEnv *objects.Storage
+	var err error
+	if f.Env != nil {
+		localEnv, err = objects.NewCallStorage(f.Env, env)
+	} else {
+		localEnv, err = objects.NewEnclosedStorage(env)
+	}
 	if err != nil {
 		return objects.NewError("%s", err.Error())
 	}
@@ -200,20 +306,54 @@ func (e *Evaluator) evalFunctionCall(fun *ast.FunctionCall, env *objects.Storage
 		localEnv.Set(param.Value, args[i])
 	}
 
+	e.pushFrame(name, f.File, localEnv)
+
 	// unwrap the returned value
 	result := e.eval(f.Body, localEnv)
-	unwrapped, ok := result.(*objects.ReturnObject)
-	if ok {
-		return unwrapped.Value
-	} else {
-		return result
+	if unwrapped, ok := result.(*objects.ReturnObject); ok {
+		result = unwrapped.Value
+	}
+	if err, ok := result.(*objects.ErrorObject); ok {
+		e.traceError(err, args)
 	}
+
+	e.popFrame(result)
+
+	return result
 }
 
+// name of frames of functions called without an identifier
+const anonymousFunction = "<anónima>"
+
+// Name of the called function for the call stack
+func functionName(callee ast.Expression) string {
+	switch callee := callee.(type) {
+	case *ast.Identifier:
+		return callee.Value
+	case *ast.MemberExpression:
+		// functions of modules, like "util.doble"
+		if object := functionName(callee.Object); object != anonymousFunction {
+			return object + "." + callee.Member.Value
+		}
+		return callee.Member.Value
+	}
+
+	return anonymousFunction
+}
+
+// The loop stops on returns, errors and exits
 func (e *Evaluator) evalForLoop(exp *ast.ForLoop, env *objects.Storage) objects.Object {
 	var value objects.Object
 	for i := 0; i < int(exp.Iterations.Value); i++ {
+		// also checked here for loops with empty bodies
+		if err := e.checkContext(); err != nil {
+			return err
+		}
+
 		value = e.evalBlockStatement(exp.Body, env)
+		if isReturn(value) || isInterruption(value) {
+			return value
+		}
 	}
 	return value
 }
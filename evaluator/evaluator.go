@@ -1,6 +1,10 @@
 package evaluator
 
 import (
+	"context"
+	"io"
+	"os"
+
 	"github.com/sl2.0/ast"
 	"github.com/sl2.0/objects"
 	"github.com/sl2.0/parser"
@@ -15,10 +19,27 @@ var (
 type Evaluator struct {
 	errors  []string
 	program *ast.Program
+
+	// visible to the programs through the "argumentos" and "entorno" builtins
+	args       []string
+	allowedEnv []string // nil allows every variable
+
+	// the evaluation stops with an error once the context is done (nil to disable)
+	ctx context.Context
+
+	budget *Budget   // nil for unlimited evaluations
+	output io.Writer // written by the print builtins
+
+	frames []*Frame // call stack
+	tracer Tracer   // nil if disabled
+
+	path    string           // file of the program ("" for inputs without a file)
+	modules *Modules         // cache of the imported modules (created when needed)
+	scope   *objects.Storage // scope of the module being evaluated (nil for programs)
 }
 
 func NewFromInput(input string) *Evaluator {
-	eval := &Evaluator{}
+	eval := &Evaluator{output: os.Stdout}
 	pars := parser.NewParser(input)
 
 	if pars == nil {
@@ -37,7 +58,7 @@ func NewFromInput(input string) *Evaluator {
 }
 
 func NewFromProgram(ast *ast.Program) *Evaluator {
-	eval := &Evaluator{}
+	eval := &Evaluator{output: os.Stdout}
 
 	if ast == nil {
 		eval.errors = append(eval.errors, "Submited an empty(nil) ast")
@@ -57,8 +78,39 @@ func (e *Evaluator) HasErrors() bool {
 	return len(e.errors) != 0
 }
 
+// Sets the arguments of the program, returned by the "argumentos" builtin
+func (e *Evaluator) SetArgs(args []string) {
+	e.args = args
+}
+
+// Restricts the environment variables readable with the "entorno" builtin to the given
+// names. A nil list allows every variable.
+func (e *Evaluator) SetAllowedEnv(names []string) {
+	e.allowedEnv = names
+}
+
+// Sets a context which stops the evaluation when it is done, like on cancellations or
+// deadlines. The context is checked before every statement.
+func (e *Evaluator) SetContext(ctx context.Context) {
+	e.ctx = ctx
+}
+
+// Sets the writer of the print builtins (the standard output by default)
+func (e *Evaluator) SetOutput(output io.Writer) {
+	e.output = output
+}
+
 func (e *Evaluator) EvalProgram(env *objects.Storage) objects.Object {
-	return e.eval(e.program, env)
+	defer e.enterFile()()
+
+	e.pushFrame(MainFrame, "", env)
+	result := e.eval(e.program, env)
+	if err, ok := result.(*objects.ErrorObject); ok {
+		e.traceError(err, nil)
+	}
+	e.popFrame(result)
+
+	return result
 }
 
 /*
@@ -69,6 +121,10 @@ So there are no global values. For statements scope dependant (like functions or
 a new env has to be created an passed to the eval function.
 */
 func (e *Evaluator) eval(node ast.Node, env *objects.Storage) objects.Object {
+	if err := e.step(); err != nil {
+		return err
+	}
+
 	switch node := node.(type) {
 	case *ast.Program:
 		return e.evalStatements(node.Statements, env)
@@ -79,7 +135,7 @@ func (e *Evaluator) eval(node ast.Node, env *objects.Storage) objects.Object {
 
 	case *ast.VarStatement:
 		val := e.eval(node.Value, env)
-		if isError(val) {
+		if isInterruption(val) {
 			return val
 		}
 
@@ -87,15 +143,22 @@ func (e *Evaluator) eval(node ast.Node, env *objects.Storage) objects.Object {
 
 	case *ast.Identifier:
 		val, ok := env.Get(node.Value)
-		if !ok {
-			return objects.NewError("Cannot resolve identifier: %s", node.Value)
+		if ok {
+			return val
+		}
+
+		if builtin, ok := e.lookupBuiltin(node.Value); ok {
+			return builtin
 		}
-		return val
+
+		return objects.NewError("Cannot resolve identifier: %s", node.Value)
 
 	case *ast.FunctionStatement:
 		f := &objects.FunctionObject{
 			Parameters: node.Parameters,
 			Body:       node.Body,
+			Env:        e.scope,
+			File:       e.moduleFile(),
 		}
 
 		env.Set(node.Identifier.Value, f)
@@ -106,6 +169,8 @@ func (e *Evaluator) eval(node ast.Node, env *objects.Storage) objects.Object {
 		f := &objects.FunctionObject{
 			Parameters: node.Parameters,
 			Body:       node.Body,
+			Env:        e.scope,
+			File:       e.moduleFile(),
 		}
 		return f
 
@@ -120,8 +185,25 @@ func (e *Evaluator) eval(node ast.Node, env *objects.Storage) objects.Object {
 
 	case *ast.ReturnStatement:
 		val := e.eval(node.ReturnValue, env)
+		if isInterruption(val) {
+			return val
+		}
+
 		return &objects.ReturnObject{Value: val}
 
+	case *ast.TryStatement:
+		return e.evalTryStatement(node, env)
+
+	case *ast.ThrowStatement:
+		return e.evalThrowStatement(node, env)
+
+	case *ast.TestStatement:
+		// tests are only run by the test runner
+		return nil
+
+	case *ast.ImportStatement:
+		return e.evalImportStatement(node, env)
+
 		// -- Expressions --
 	case *ast.PrefixExpression:
 		return e.evalPrefix(node, env)
@@ -142,7 +224,13 @@ func (e *Evaluator) eval(node ast.Node, env *objects.Storage) objects.Object {
 		return false_obj
 
 	case *ast.StringLiteral:
-		return &objects.String{Value: node.Value}
+		return e.allocate(&objects.String{Value: node.Value})
+
+	case *ast.InterpolatedString:
+		return e.evalInterpolatedString(node, env)
+
+	case *ast.MemberExpression:
+		return e.evalMemberExpression(node, env)
 	}
 
 	return objects.NewError("Cannot evaluate node: %s", node.ToString(0))
@@ -152,11 +240,17 @@ func (e *Evaluator) evalBlockStatement(node *ast.BlockStatement, env *objects.St
 	var res objects.Object
 
 	for _, value := range node.Statements {
+		// checked before the tracer, which must not stop on stopped evaluations
+		if err := e.checkContext(); err != nil {
+			return err
+		}
+		e.enterStatement(value)
+
 		res = e.eval(value, env)
 
 		if res != nil {
 			rt := res.Type()
-			if rt == objects.RETURN_OBJ || rt == objects.ERROR_OBJ {
+			if rt == objects.RETURN_OBJ || rt == objects.ERROR_OBJ || rt == objects.EXIT_OBJ {
 				return res
 			}
 		}
@@ -169,13 +263,19 @@ func (e *Evaluator) evalStatements(stmts []ast.Statement, env *objects.Storage)
 	var res objects.Object
 
 	for _, value := range stmts {
+		// checked before the tracer, which must not stop on stopped evaluations
+		if err := e.checkContext(); err != nil {
+			return err
+		}
+		e.enterStatement(value)
+
 		res = e.eval(value, env)
 
 		switch res := res.(type) {
 		case *objects.ReturnObject:
 			return res.Value
 
-		case *objects.ErrorObject:
+		case *objects.ErrorObject, *objects.ExitObject:
 			return res
 		}
 	}
@@ -183,12 +283,21 @@ func (e *Evaluator) evalStatements(stmts []ast.Statement, env *objects.Storage)
 	return res
 }
 
+// Returns an error object if the context of the evaluation is done
+func (e *Evaluator) checkContext() objects.Object {
+	if e.ctx == nil || e.ctx.Err() == nil {
+		return nil
+	}
+
+	return objects.NewErrorOfKind(objects.CANCEL_ERROR, "Evaluation stopped: %s", e.ctx.Err())
+}
+
 func (e *Evaluator) evalExpressions(exps []ast.Expression, env *objects.Storage) []objects.Object {
 	var res []objects.Object
 
 	for _, value := range exps {
 		r := e.eval(value, env)
-		if isError(r) {
+		if isInterruption(r) {
 			return []objects.Object{r}
 		}
 
@@ -207,6 +316,17 @@ func isError(obj objects.Object) bool {
 	return false
 }
 
+// Reports if the object stops the evaluation (errors and exits), so it has to be
+// returned without evaluating anything else
+func isInterruption(obj objects.Object) bool {
+	if obj != nil {
+		rt := obj.Type()
+		return rt == objects.ERROR_OBJ || rt == objects.EXIT_OBJ
+	}
+
+	return false
+}
+
 func isReturn(obj objects.Object) bool {
 	if obj != nil {
 		rt := obj.Type()
@@ -1,10 +1,15 @@
 package evaluator
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sl2.0/objects"
+	"github.com/sl2.0/parser"
 )
 
 func TestIntegerEvaluation(t *testing.T) {
@@ -66,6 +71,9 @@ func TestInfixArithmetic(t *testing.T) {
 		{tcase: "-12 - 12 * -2 ", expected: 12},
 		{tcase: "(-12 + 24) * 2 ", expected: 24},
 		{tcase: "-(11 + 1) * 2 ", expected: -24},
+		{tcase: "0xFF + 0b1010 + 0o10", expected: 273},
+		{tcase: "1_000_000 / 0x_3E8", expected: 1000},
+		{tcase: "var a = \n 2 +\n 3 *\n\n (\n 4 - 1); a", expected: 11},
 	}
 
 	for _, tc := range testCases {
@@ -99,6 +107,51 @@ func TestInfixStrings(t *testing.T) {
 	}
 }
 
+func TestInterpolatedStrings(t *testing.T) {
+	testCases := []struct {
+		tcase    string
+		expected string
+	}{
+		{tcase: `var nombre = "Ana"; "Hola ${nombre}"`, expected: "Hola Ana"},
+		{tcase: `var edad = 20; "tienes ${edad + 1} años"`, expected: "tienes 21 años"},
+		{tcase: `"${1 == 1} y ${"anidado ${2 * 3}"}"`, expected: "true y anidado 6"},
+		{tcase: `"${si (true) { "a" } sino { "b" }}!"`, expected: "a!"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := parseAndEval(t, tc.tcase)
+
+		if evaluated == nil {
+			continue
+		}
+
+		testString(t, evaluated, tc.expected)
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	testCases := []struct {
+		tcase    string
+		expected int64
+	}{
+		{tcase: `var año = 2024; año + 1`, expected: 2025},
+		{tcase: "func número(ñ) { retorna ñ * 2; }\n número(21)", expected: 42},
+		{tcase: `longitud("año")`, expected: 3},
+		{tcase: `longitud("¿qué tal? ☕")`, expected: 11},
+		{tcase: `var longitud = 2; longitud`, expected: 2},
+	}
+
+	for _, tc := range testCases {
+		evaluated := parseAndEval(t, tc.tcase)
+
+		if evaluated == nil {
+			continue
+		}
+
+		testInteger(t, evaluated, tc.expected)
+	}
+}
+
 func TestInfixComparison(t *testing.T) {
 	testCases := []struct {
 		tcase    string
@@ -316,3 +369,341 @@ func TestMaxRecursion(t *testing.T) {
 		t.Errorf("Expected msg '%s'. Got %s", expected, evaluated.Inspect())
 	}
 }
+
+func TestProcessBuiltins(t *testing.T) {
+	t.Setenv("SL_PRUEBA", "valor")
+
+	testCases := []struct {
+		tcase      string
+		allowedEnv []string
+		expected   string
+	}{
+		{tcase: `argumentos()`, expected: "[uno, dos]"},
+		{tcase: `argumentos(1)`, expected: "dos"},
+		{tcase: `longitud(argumentos())`, expected: "2"},
+		{tcase: `argumentos(2)`, expected: "argumentos: index 2 out of range. The program has 2 arguments"},
+		{tcase: `entorno("SL_PRUEBA")`, expected: "valor"},
+		{tcase: `entorno("SL_PRUEBA")`, allowedEnv: []string{"SL_PRUEBA"}, expected: "valor"},
+		{tcase: `entorno("SL_PRUEBA")`, allowedEnv: []string{}, expected: "entorno: access to the variable 'SL_PRUEBA' is not allowed"},
+		{tcase: `entorno("SL_NO_DEFINIDA")`, expected: ""},
+	}
+
+	for _, tc := range testCases {
+		ev := NewFromProgram(parser.NewParser(tc.tcase).ParseProgram())
+		ev.SetArgs([]string{"uno", "dos"})
+		ev.SetAllowedEnv(tc.allowedEnv)
+
+		evaluated := ev.EvalProgram(objects.NewStorage())
+		if evaluated == nil || evaluated.Inspect() != tc.expected {
+			t.Errorf("%s: expected '%s'. Got %v", tc.tcase, tc.expected, evaluated)
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+	testCases := []struct {
+		tcase    string
+		expected int64
+	}{
+		{tcase: `salir(); 2`, expected: 0},
+		{tcase: `salir(3); 2`, expected: 3},
+		{tcase: `var a = 1 + salir(4); a`, expected: 4},
+		{tcase: "func f() { salir(5); retorna 1; }\nf() + f()", expected: 5},
+		{tcase: `repetir 10 { si (true) { salir(6) } } 1`, expected: 6},
+		{tcase: `"${salir(7)}"`, expected: 7},
+	}
+
+	for _, tc := range testCases {
+		evaluated := parseAndEval(t, tc.tcase)
+
+		exit, ok := evaluated.(*objects.ExitObject)
+		if !ok {
+			t.Errorf("%s: expected an exit. Got %v", tc.tcase, evaluated)
+			continue
+		}
+
+		if exit.Code != tc.expected {
+			t.Errorf("%s: expected status %d. Got %d", tc.tcase, tc.expected, exit.Code)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	testCases := []struct {
+		tcase    string
+		limits   Limits
+		expected string
+	}{
+		{tcase: `repetir 100 { 1 + 1 }`, limits: Limits{MaxSteps: 50}, expected: "Limit exceeded: more than 50 evaluation steps"},
+		{tcase: `repetir 10 { 1 + 1 }`, limits: Limits{MaxSteps: 50}, expected: "2"},
+		{tcase: `var s = "abc"; repetir 10 { var s = s + s; }
+longitud(s)`, limits: Limits{MaxMemory: 1000},
+			expected: "Limit exceeded: more than 1000 bytes of memory"},
+		{tcase: `var s = "abc"; repetir 3 { var s = s + s; }
+longitud(s)`, limits: Limits{MaxMemory: 1000}, expected: "24"},
+		{tcase: `repetir 10 { imprimir("hola") }`, limits: Limits{MaxOutput: 12}, expected: "Limit exceeded: more than 12 bytes of output"},
+	}
+
+	for _, tc := range testCases {
+		var out strings.Builder
+
+		ev := NewFromProgram(parser.NewParser(tc.tcase).ParseProgram())
+		ev.SetBudget(NewBudget(tc.limits))
+		ev.SetOutput(&out)
+
+		evaluated := ev.EvalProgram(objects.NewStorage())
+		if evaluated == nil || evaluated.Inspect() != tc.expected {
+			t.Errorf("%s: expected '%s'. Got %v", tc.tcase, tc.expected, evaluated)
+			continue
+		}
+
+		if err, ok := evaluated.(*objects.ErrorObject); ok && err.Kind != objects.LIMIT_ERROR {
+			t.Errorf("%s: expected a limit error. Got %s", tc.tcase, err.Kind)
+		}
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var out strings.Builder
+
+	ev := NewFromProgram(parser.NewParser(`imprimir("a", 1, true); imprimir(); imprimir("${2 * 3}")`).ParseProgram())
+	ev.SetOutput(&out)
+	ev.EvalProgram(objects.NewStorage())
+
+	expected := "a 1 true\n\n6\n"
+	if out.String() != expected {
+		t.Errorf("expected output %q. Got %q", expected, out.String())
+	}
+}
+
+func TestStackTrace(t *testing.T) {
+	input := `func fibonacci(n) {
+    si (n < 2) {
+        retorna n + desconocido
+    }
+    retorna fibonacci(n - 1) + fibonacci(n - 2)
+}
+
+func saludar(nombre, f) {
+    retorna f(2)
+}
+
+var x = 1
+saludar("un nombre bastante largo", fibonacci)`
+
+	evaluated := parseAndEval(t, input)
+	err, ok := evaluated.(*objects.ErrorObject)
+	if !ok {
+		t.Fatalf("expected an error. Got %v", evaluated)
+	}
+
+	// frames are named after the called identifier, and their positions are the ones of
+	// the error or the call to the next frame
+	expected := []objects.TraceFrame{
+		{Function: "fibonacci", Args: "1", Line: 3, Column: 9},
+		{Function: "f", Args: "2", Line: 5, Column: 13},
+		{Function: "saludar", Args: `"un nombre bastante…, func`, Line: 9, Column: 13},
+		{Function: "main", Line: 13, Column: 1},
+	}
+	if len(err.Trace) != len(expected) {
+		t.Fatalf("expected %d frames. Got %v", len(expected), err.Trace)
+	}
+	for n, frame := range expected {
+		if err.Trace[n] != frame {
+			t.Errorf("frame %d: expected %+v. Got %+v", n, frame, err.Trace[n])
+		}
+	}
+
+	// recursions are collapsed
+	evaluated = parseAndEval(t, "func rec(n) {\n    retorna rec(n + 1)\n}\nrec(1)")
+	trace := evaluated.(*objects.ErrorObject).StackTrace()
+	expectedTrace := "  en rec (línea 2)\n  ... repetido 199 veces más\n  en main (línea 4)"
+	if trace != expectedTrace {
+		t.Errorf("expected trace %q. Got %q", expectedTrace, trace)
+	}
+}
+
+func TestExceptions(t *testing.T) {
+	testCases := []struct {
+		tcase    string
+		expected string
+	}{
+		{tcase: "var r = 0\nintentar {\n    var r = 1 / 0\n} capturar (e) {\n    var r = e.mensaje\n}\nr", expected: "Division by zero"},
+		{tcase: "var r = 0\nintentar {\n    x\n} capturar (e) {\n    var r = \"${e.tipo} ${e.linea}:${e.columna}\"\n}\nr", expected: "RuntimeError 3:5"},
+		{tcase: "var r = 0\nintentar {\n    lanzar \"malo\"\n} capturar (e) {\n    var r = e\n}\n\"${r}\"", expected: "UserError: malo"},
+		{tcase: "var r = \"\"\nintentar {\n    var r = r + \"a\"\n} finalmente {\n    var r = r + \"b\"\n}\nr", expected: "ab"},
+		{tcase: "func f() {\n    intentar {\n        lanzar \"a\"\n    } capturar (e) {\n        retorna \"c\"\n    } finalmente {\n        retorna \"f\"\n    }\n}\nf()", expected: "f"},
+		{tcase: "var r = \"\"\nintentar {\n    intentar {\n        lanzar \"a\"\n    } finalmente {\n        var r = \"f\"\n    }\n} capturar (e) {\n    var r = r + e.mensaje\n}\nr", expected: "fa"},
+		// the position of errors raised inside calls is the innermost statement
+		{tcase: "func f() {\n    retorna 1 / 0\n}\nvar r = 0\nintentar {\n    f()\n} capturar (e) {\n    var r = \"${e.linea}\"\n}\nr", expected: "2"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := parseAndEval(t, tc.tcase)
+		if evaluated == nil {
+			t.Errorf("%s: expected a value", tc.tcase)
+			continue
+		}
+		testString(t, evaluated, tc.expected)
+	}
+
+	// thrown values are kept, and caught exceptions can be thrown again
+	testInteger(t, parseAndEval(t, "var r = 0\nintentar {\n    lanzar 5\n} capturar (e) {\n    var r = e.valor\n}\nr"), 5)
+
+	evaluated := parseAndEval(t, "intentar {\n    lanzar 5\n} capturar (e) {\n    lanzar e\n}\n")
+	if err, ok := evaluated.(*objects.ErrorObject); !ok || err.Kind != objects.USER_ERROR || err.Inspect() != "5" {
+		t.Errorf("expected the user error 5 thrown again. Got %v", evaluated)
+	}
+
+	// exits and limits cannot be caught
+	for _, tcase := range []string{
+		"intentar {\n    salir(3)\n} capturar (e) {\n    1\n}\n",
+		"intentar {\n    salir(3)()\n} capturar (e) {\n    1\n}\n",
+	} {
+		evaluated = parseAndEval(t, tcase)
+		if _, ok := evaluated.(*objects.ExitObject); !ok {
+			t.Errorf("%s: expected an exit. Got %v", tcase, evaluated)
+		}
+	}
+
+	uncaught := func(input string, kind objects.ErrorKind, setup func(ev *Evaluator)) {
+		ev := NewFromProgram(parser.NewParser(input).ParseProgram())
+		setup(ev)
+
+		evaluated := ev.EvalProgram(objects.NewStorage())
+		if err, ok := evaluated.(*objects.ErrorObject); !ok || err.Kind != kind {
+			t.Errorf("%s: expected an error of kind %s. Got %v", input, kind, evaluated)
+		}
+	}
+
+	uncaught("intentar {\n    repetir 100 { 1 + 1 }\n} capturar (e) {\n    1\n}\n", objects.LIMIT_ERROR, func(ev *Evaluator) {
+		ev.SetBudget(NewBudget(Limits{MaxSteps: 50}))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	uncaught("intentar {\n    repetir 1000000000 { 1 }\n} capturar (e) {\n    1\n}\n", objects.CANCEL_ERROR, func(ev *Evaluator) {
+		ev.SetContext(ctx)
+	})
+}
+
+func TestAssertions(t *testing.T) {
+	testCases := []struct {
+		tcase    string
+		expected string // message of the failure (empty if it passes)
+	}{
+		// passing assertions have no value, so those cases end with another expression
+		{tcase: `afirmar(1 < 2); 1`, expected: ""},
+		{tcase: `afirmar(2 < 1)`, expected: "Assertion failed"},
+		{tcase: `afirmar(2 < 1, "dos es mayor")`, expected: "dos es mayor"},
+		{tcase: `afirmar_igual(1 + 1, 2); 1`, expected: ""},
+		{tcase: `afirmar_igual(argumentos(), argumentos()); 1`, expected: ""},
+		{tcase: `afirmar_igual("2", 2)`, expected: `Expected 2. Got "2"`},
+		{tcase: "func f() {}\nafirmar_igual(f(), 1)", expected: "Expected 1. Got no value"},
+		// failed assertions cannot be caught
+		{tcase: "intentar {\n    afirmar(false)\n} capturar (e) {\n    1\n}\n", expected: "Assertion failed"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := parseAndEval(t, tc.tcase)
+
+		err, failed := evaluated.(*objects.ErrorObject)
+		if tc.expected == "" {
+			if failed {
+				t.Errorf("%s: unexpected failure: %s", tc.tcase, err.Inspect())
+			}
+			continue
+		}
+
+		if !failed || err.Kind != objects.ASSERT_ERROR || err.Inspect() != tc.expected {
+			t.Errorf("%s: expected the failure %q. Got %v", tc.tcase, tc.expected, evaluated)
+		}
+	}
+
+	// tests are skipped when running a program
+	testInteger(t, parseAndEval(t, "prueba \"nunca\" {\n    salir(1)\n}\n2"), 2)
+}
+
+func TestImports(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"lib/util.sl": "var factor = 3\nfunc triple(n) {\n    retorna n * factor\n}\nimprimir(\"cargado\")\n",
+		"lib/otro.sl": "importar \"util.sl\" como u\nvar nueve = u.triple(3)\n",
+		"ciclo_a.sl":  "importar \"ciclo_b.sl\" como b\n",
+		"ciclo_b.sl":  "importar \"ciclo_a.sl\" como a\n",
+		"roto.sl":     "var x = (1\n",
+		"falla.sl":    "func dividir(n) {\n    retorna n / 0\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eval := func(input string) (objects.Object, string) {
+		var out strings.Builder
+		ev := NewFromProgram(parser.NewParser(input).ParseProgram())
+		ev.SetOutput(&out)
+		ev.SetPath(filepath.Join(dir, "main.sl"))
+		return ev.EvalProgram(objects.NewStorage()), out.String()
+	}
+
+	// functions of modules see their module, and modules are evaluated once
+	evaluated, out := eval("importar \"lib/util.sl\" como util\nimportar \"lib/otro.sl\" como otro\nvar factor = 100\nutil.triple(2) + otro.nueve")
+	testInteger(t, evaluated, 15)
+	if out != "cargado\n" {
+		t.Errorf("expected the module to be evaluated once. Got output %q", out)
+	}
+
+	// frames of the functions of modules are named after the module, and show its file
+	evaluated, _ = eval("importar \"falla.sl\" como f\nf.dividir(1)")
+	err, ok := evaluated.(*objects.ErrorObject)
+	if !ok {
+		t.Fatalf("expected an error. Got %v", evaluated)
+	}
+	expectedTrace := "  en f.dividir (falla.sl, línea 2)\n  en main (línea 2)"
+	if trace := err.StackTrace(); trace != expectedTrace {
+		t.Errorf("expected trace %q. Got %q", expectedTrace, trace)
+	}
+
+	testCases := []struct {
+		tcase    string
+		expected string
+	}{
+		{tcase: "importar \"lib/util.sl\" como util\nutil.nada", expected: "Member 'nada' not found on module util"},
+		{tcase: "importar \"nada.sl\" como n", expected: "Cannot import 'nada.sl': file not found"},
+		{tcase: "importar \"ciclo_a.sl\" como a", expected: "Cannot import 'ciclo_a.sl': line 1: Cannot import 'ciclo_b.sl': line 1: Import cycle: ciclo_a.sl -> ciclo_b.sl -> ciclo_a.sl"},
+		{tcase: "importar \"roto.sl\" como r", expected: "Cannot import 'roto.sl':\n\tExpected 'RPAR'. Got LINEBREAK"},
+		{tcase: "importar \"mate\" como m\nm.nada()", expected: "Member 'nada' not found on module mate"},
+		{tcase: "importar \"mate\" como m\nm.raiz(-1)", expected: "mate.raiz: cannot take the root of a negative number. Got -1"},
+	}
+
+	for _, tc := range testCases {
+		evaluated, _ := eval(tc.tcase)
+		err, ok := evaluated.(*objects.ErrorObject)
+		if !ok {
+			t.Errorf("%s: expected an error. Got %v", tc.tcase, evaluated)
+			continue
+		}
+		if err.Inspect() != tc.expected {
+			t.Errorf("expected error %q. Got %q", tc.expected, err.Inspect())
+		}
+	}
+}
+
+func TestStdlibImports(t *testing.T) {
+	evaluated := parseAndEval(t, "importar \"mate\" como mate\nimportar \"conv\" como conv\nconv.formatear(mate.potencia(2, 8), 16)")
+	testString(t, evaluated, "100")
+
+	// the strings made by the standard library are counted on the memory limit
+	ev := NewFromProgram(parser.NewParser("importar \"texto\" como t\nt.replicar(\"abc\", 100)").ParseProgram())
+	ev.SetBudget(NewBudget(Limits{MaxMemory: 100}))
+	err, ok := ev.EvalProgram(objects.NewStorage()).(*objects.ErrorObject)
+	if !ok || err.Kind != objects.LIMIT_ERROR {
+		t.Errorf("expected a limit error. Got %v", err)
+	}
+}
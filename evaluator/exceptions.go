@@ -0,0 +1,139 @@
+package evaluator
+
+import (
+	"github.com/sl2.0/ast"
+	"github.com/sl2.0/objects"
+)
+
+// Catches the errors of the body which can be caught (see ErrorObject.Catchable), binding
+// them to the identifier of the catch block. The finally block runs after the others
+// unless the evaluation was stopped, and its returns and errors replace the result.
+func (e *Evaluator) evalTryStatement(stmt *ast.TryStatement, env *objects.Storage) objects.Object {
+	result := e.eval(stmt.Body, env)
+
+	err, failed := result.(*objects.ErrorObject)
+	if failed && !err.Catchable() {
+		return result
+	}
+
+	if failed && stmt.Catch != nil {
+		env.Set(stmt.Identifier.Value, e.newException(err))
+		result = e.eval(stmt.Catch, env)
+	}
+
+	if stmt.Finally == nil {
+		return result
+	}
+
+	if err, ok := result.(*objects.ErrorObject); ok && !err.Catchable() {
+		return result
+	}
+	if result != nil && result.Type() == objects.EXIT_OBJ {
+		return result
+	}
+
+	finally := e.eval(stmt.Finally, env)
+	if isReturn(finally) || isInterruption(finally) {
+		return finally
+	}
+
+	return result
+}
+
+// The position of the error is the innermost frame of its trace, or the statement being
+// evaluated if it did not unwind any call
+func (e *Evaluator) newException(err *objects.ErrorObject) *objects.Exception {
+	exception := &objects.Exception{
+		Message: err.Inspect(),
+		Kind:    err.Kind,
+		Value:   err.Value,
+	}
+
+	if len(err.Trace) > 0 {
+		exception.Line, exception.Column = err.Trace[0].Line, err.Trace[0].Column
+	} else if len(e.frames) > 0 {
+		frame := e.frames[len(e.frames)-1]
+		exception.Line, exception.Column = frame.Line, frame.Column
+	}
+
+	return exception
+}
+
+// Thrown exceptions keep their kind and value, so caught errors can be thrown again
+func (e *Evaluator) evalThrowStatement(stmt *ast.ThrowStatement, env *objects.Storage) objects.Object {
+	value := e.eval(stmt.Value, env)
+	if isInterruption(value) {
+		return value
+	}
+
+	if value == nil {
+		return objects.NewError("Cannot throw an expression without value: %s", stmt.Value.ToString(0))
+	}
+
+	if exception, ok := value.(*objects.Exception); ok {
+		err := objects.NewErrorOfKind(exception.Kind, "%s", exception.Message).(*objects.ErrorObject)
+		err.Value = exception.Value
+		return err
+	}
+
+	err := objects.NewErrorOfKind(objects.USER_ERROR, "%s", value.Inspect()).(*objects.ErrorObject)
+	err.Value = value
+	return err
+}
+
+// Members of exceptions and values of maps with string keys
+func (e *Evaluator) evalMemberExpression(exp *ast.MemberExpression, env *objects.Storage) objects.Object {
+	object := e.eval(exp.Object, env)
+	if isInterruption(object) {
+		return object
+	}
+
+	name := exp.Member.Value
+
+	switch object := object.(type) {
+	case *objects.Exception:
+		switch name {
+		case "mensaje", "message":
+			return &objects.String{Value: object.Message}
+		case "tipo", "kind":
+			return &objects.String{Value: string(object.Kind)}
+		case "linea", "line":
+			return &objects.Integer{Value: int64(object.Line)}
+		case "columna", "column":
+			return &objects.Integer{Value: int64(object.Column)}
+		case "valor", "value":
+			if object.Value == nil {
+				return &objects.String{Value: object.Message}
+			}
+			return object.Value
+		}
+
+	case *objects.Module:
+		value, ok := object.Env.Get(name)
+		if !ok {
+			return objects.NewError("Member '%s' not found on module %s", name, object.Name)
+		}
+
+		// the values made by the standard library are counted like the ones of the
+		// builtins, on the budget of the current evaluation
+		if builtin, ok := value.(*objects.BuiltinFunction); ok && object.Path == "" {
+			return &objects.BuiltinFunction{
+				Name: builtin.Name,
+				Fn: func(args ...objects.Object) objects.Object {
+					return e.allocate(builtin.Fn(args...))
+				},
+			}
+		}
+
+		return value
+
+	case *objects.Map:
+		for _, pair := range object.Pairs {
+			if key, ok := pair.Key.(*objects.String); ok && key.Value == name {
+				return pair.Value
+			}
+		}
+	}
+
+	return objects.NewError("Member '%s' not found on value of type %s", name, typeName(object))
+}
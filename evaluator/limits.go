@@ -0,0 +1,94 @@
+package evaluator
+
+import (
+	"github.com/sl2.0/objects"
+)
+
+// Limits of the resources used by the programs, for running untrusted code. Zero
+// values disable a limit.
+type Limits struct {
+	MaxSteps  int64 // evaluated nodes of the AST
+	MaxMemory int64 // approximate bytes allocated by strings and lists
+	MaxOutput int64 // bytes written by the print builtins
+}
+
+// Resources used by the evaluations which share the budget. Exceeding any limit ends the
+// evaluation with an error of kind LIMIT_ERROR.
+type Budget struct {
+	Limits
+
+	Steps  int64
+	Memory int64
+	Output int64
+}
+
+func NewBudget(limits Limits) *Budget {
+	return &Budget{Limits: limits}
+}
+
+// approximate size of the header of a value inside a list
+const elementSize = 16
+
+// Sets the budget of the evaluation. Many evaluators can share the same budget.
+func (e *Evaluator) SetBudget(budget *Budget) {
+	e.budget = budget
+}
+
+// Counts an evaluated node
+func (e *Evaluator) step() objects.Object {
+	if e.budget == nil {
+		return nil
+	}
+
+	e.budget.Steps++
+	if e.budget.MaxSteps > 0 && e.budget.Steps > e.budget.MaxSteps {
+		return objects.NewErrorOfKind(objects.LIMIT_ERROR, "Limit exceeded: more than %d evaluation steps", e.budget.MaxSteps)
+	}
+
+	return nil
+}
+
+// Counts the bytes of a new value. Returns the value, or an error if the memory limit
+// is exceeded.
+func (e *Evaluator) allocate(value objects.Object) objects.Object {
+	if e.budget == nil {
+		return value
+	}
+
+	e.budget.Memory += sizeOf(value)
+	if e.budget.MaxMemory > 0 && e.budget.Memory > e.budget.MaxMemory {
+		return objects.NewErrorOfKind(objects.LIMIT_ERROR, "Limit exceeded: more than %d bytes of memory", e.budget.MaxMemory)
+	}
+
+	return value
+}
+
+// Counts the bytes written by a print builtin, before writing them
+func (e *Evaluator) useOutput(bytes int) objects.Object {
+	if e.budget == nil {
+		return nil
+	}
+
+	e.budget.Output += int64(bytes)
+	if e.budget.MaxOutput > 0 && e.budget.Output > e.budget.MaxOutput {
+		return objects.NewErrorOfKind(objects.LIMIT_ERROR, "Limit exceeded: more than %d bytes of output", e.budget.MaxOutput)
+	}
+
+	return nil
+}
+
+// approximate size of the content of strings and lists (other values are not counted)
+func sizeOf(value objects.Object) int64 {
+	switch value := value.(type) {
+	case *objects.String:
+		return int64(len(value.Value))
+	case *objects.Array:
+		size := int64(len(value.Elements)) * elementSize
+		for _, element := range value.Elements {
+			size += sizeOf(element)
+		}
+		return size
+	}
+
+	return 0
+}
@@ -0,0 +1,213 @@
+package evaluator
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sl2.0/ast"
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/objects"
+	"github.com/sl2.0/parser"
+	"github.com/sl2.0/stdlib"
+	"github.com/sl2.0/tokens"
+)
+
+/*
+Modules are files loaded with "importar", which are evaluated once on their own scope:
+
+	importar "util.sl" como util
+	util.doble(2)
+
+Relative paths are resolved from the directory of the importing file (or the working
+directory for inputs without a file). Every module is cached by its absolute path, so
+importing it again (from any file) returns the same module without evaluating it. The
+modules of the standard library are imported by name instead of by path, like "mate".
+
+The functions of a module see the scope of their module instead of the one of their
+callers, so they can use the rest of the definitions of the module.
+*/
+
+// Cache of the modules loaded by the evaluations which share it
+type Modules struct {
+	keywords *tokens.KeywordSet // set of the modules without a pragma
+
+	loaded  map[string]*objects.Module // by absolute path (or name for the standard library)
+	loading []string                   // files being evaluated, to detect cycles
+}
+
+func NewModules(keywords *tokens.KeywordSet) *Modules {
+	return &Modules{
+		keywords: keywords,
+		loaded:   map[string]*objects.Module{},
+	}
+}
+
+// Sets the file of the program, used to resolve its imports and detect cycles. An empty
+// path resolves the imports from the working directory.
+func (e *Evaluator) SetPath(path string) {
+	if path == "" {
+		e.path = ""
+		return
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	e.path = path
+}
+
+// Sets the cache of the modules imported by the program. Many evaluators can share the
+// same cache.
+func (e *Evaluator) SetModules(modules *Modules) {
+	e.modules = modules
+}
+
+func (e *Evaluator) moduleCache() *Modules {
+	if e.modules == nil {
+		e.modules = NewModules(tokens.Spanish)
+	}
+
+	return e.modules
+}
+
+func (e *Evaluator) evalImportStatement(stmt *ast.ImportStatement, env *objects.Storage) objects.Object {
+	var module objects.Object
+	if path := stmt.Path.Value; stdlib.IsModule(path) {
+		module = e.importStdlib(path)
+	} else {
+		if !filepath.IsAbs(path) && e.path != "" {
+			path = filepath.Join(filepath.Dir(e.path), path)
+		}
+		module = e.importModule(stmt.Path.Value, path)
+	}
+
+	if isInterruption(module) {
+		return module
+	}
+
+	return env.Set(stmt.Name.Value, module)
+}
+
+// Returns the module of the file, evaluating it the first time. The name is the path
+// written on the import, for the error messages.
+func (e *Evaluator) importModule(name, path string) objects.Object {
+	modules := e.moduleCache()
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return objects.NewError("Cannot import '%s': %s", name, err.Error())
+	}
+
+	if module, ok := modules.loaded[path]; ok {
+		return module
+	}
+
+	for i, loading := range modules.loading {
+		if loading == path {
+			return objects.NewError("Import cycle: %s", cycle(append(modules.loading[i:], path)))
+		}
+	}
+
+	src, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return objects.NewError("Cannot import '%s': file not found", name)
+	} else if err != nil {
+		return objects.NewError("Cannot import '%s': %s", name, err.Error())
+	}
+
+	p := parser.NewParserFromLexer(lexer.NewLexerWithKeywords(string(src), modules.keywords))
+	program := p.ParseProgram()
+	if p.HasErrors() {
+		return objects.NewError("Cannot import '%s':\n\t%s", name, strings.Join(p.Errors(), "\n\t"))
+	}
+
+	module := &objects.Module{
+		Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Path: path,
+		Env:  objects.NewStorage(),
+	}
+
+	result := e.newModuleEvaluator(program, module).EvalProgram(module.Env)
+	switch result := result.(type) {
+	case *objects.ErrorObject:
+		line := 0
+		if len(result.Trace) > 0 {
+			line = result.Trace[0].Line
+		}
+		return objects.NewErrorOfKind(result.Kind, "Cannot import '%s': line %d: %s", name, line, result.Inspect())
+
+	case *objects.ExitObject:
+		return result
+	}
+
+	modules.loaded[path] = module
+
+	return module
+}
+
+// Returns the module of the standard library, creating it the first time
+func (e *Evaluator) importStdlib(name string) objects.Object {
+	modules := e.moduleCache()
+	if module, ok := modules.loaded[name]; ok {
+		return module
+	}
+
+	module, _ := stdlib.Load(name)
+	modules.loaded[name] = module
+
+	return module
+}
+
+// Returns an evaluator of the module with the resources and output of this one. Modules
+// are evaluated without tracer, because the positions of their statements belong to
+// other files.
+func (e *Evaluator) newModuleEvaluator(program *ast.Program, module *objects.Module) *Evaluator {
+	return &Evaluator{
+		program:    program,
+		args:       e.args,
+		allowedEnv: e.allowedEnv,
+		ctx:        e.ctx,
+		budget:     e.budget,
+		output:     e.output,
+		path:       module.Path,
+		modules:    e.modules,
+		scope:      module.Env,
+	}
+}
+
+// File of the module being evaluated ("" for programs)
+func (e *Evaluator) moduleFile() string {
+	if e.scope == nil {
+		return ""
+	}
+
+	return e.path
+}
+
+// Marks the file of the program as being evaluated until the returned function is called
+func (e *Evaluator) enterFile() func() {
+	if e.path == "" {
+		return func() {}
+	}
+
+	modules := e.moduleCache()
+	modules.loading = append(modules.loading, e.path)
+
+	return func() {
+		modules.loading = modules.loading[:len(modules.loading)-1]
+	}
+}
+
+// Chain of files of an import cycle, like "a.sl -> b.sl -> a.sl"
+func cycle(paths []string) string {
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+
+	return strings.Join(names, " -> ")
+}
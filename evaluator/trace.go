@@ -0,0 +1,174 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/sl2.0/ast"
+	"github.com/sl2.0/objects"
+)
+
+// name of the frame of the program itself
+const MainFrame = "main"
+
+// Frame of the call stack. The first frame of an evaluation is the program itself.
+type Frame struct {
+	Function string // name of the called function ("main" for the program)
+	File     string // file of the function if it belongs to a module ("" for the program)
+
+	// position of the statement being evaluated on the frame. For every frame but the
+	// last one, it is the position of the call to the next frame.
+	Line   int
+	Column int
+
+	Env *objects.Storage // scope of the call
+}
+
+// Tracer receives the events of an evaluation, for tools like debuggers and profilers.
+// Events are received on the goroutine of the evaluation, which waits for them. The
+// statements and branches of the functions of imported modules are not traced, because
+// their positions belong to other files.
+type Tracer interface {
+	// Before evaluating a statement. The last frame is the one running the statement.
+	OnStatement(e *Evaluator, stmt ast.Statement)
+	// After pushing the frame of a call to a function of the language
+	OnCall(e *Evaluator, frame *Frame)
+	// Before popping the frame of a call, with the returned value
+	OnReturn(e *Evaluator, frame *Frame, result objects.Object)
+}
+
+// Optional interface of the tracers which also receive the branch taken by every "si"
+// expression, like the coverage reports
+type BranchTracer interface {
+	// After evaluating the condition. taken reports if it was true.
+	OnBranch(e *Evaluator, exp *ast.IfExpression, taken bool)
+}
+
+// Tracer which ignores every event. Embed it to implement only some events.
+type NopTracer struct{}
+
+func (NopTracer) OnStatement(*Evaluator, ast.Statement)       {}
+func (NopTracer) OnCall(*Evaluator, *Frame)                   {}
+func (NopTracer) OnReturn(*Evaluator, *Frame, objects.Object) {}
+
+// Sets the tracer of the evaluation (nil to disable it)
+func (e *Evaluator) SetTracer(tracer Tracer) {
+	e.tracer = tracer
+}
+
+// Returns a copy of the call stack, starting from the program itself
+func (e *Evaluator) Frames() []Frame {
+	frames := make([]Frame, 0, len(e.frames))
+	for _, frame := range e.frames {
+		frames = append(frames, *frame)
+	}
+
+	return frames
+}
+
+// Number of frames of the call stack
+func (e *Evaluator) Depth() int {
+	return len(e.frames)
+}
+
+// Pushes a new frame. The first one is always the program itself.
+func (e *Evaluator) pushFrame(name, file string, env *objects.Storage) *Frame {
+	if len(e.frames) == 0 && name != MainFrame {
+		e.frames = append(e.frames, &Frame{Function: MainFrame, Env: env})
+	}
+
+	frame := &Frame{Function: name, File: file, Env: env}
+	e.frames = append(e.frames, frame)
+
+	if e.tracer != nil && name != MainFrame {
+		e.tracer.OnCall(e, frame)
+	}
+
+	return frame
+}
+
+func (e *Evaluator) popFrame(result objects.Object) {
+	frame := e.frames[len(e.frames)-1]
+	if e.tracer != nil && frame.Function != MainFrame {
+		e.tracer.OnReturn(e, frame, result)
+	}
+
+	e.frames = e.frames[:len(e.frames)-1]
+}
+
+// Updates the position of the current frame and notifies the tracer
+func (e *Evaluator) enterStatement(stmt ast.Statement) {
+	if len(e.frames) > 0 {
+		frame := e.frames[len(e.frames)-1]
+		frame.Line, frame.Column = stmt.Position()
+	}
+
+	if e.tracer != nil && !e.inModule() {
+		e.tracer.OnStatement(e, stmt)
+	}
+}
+
+// Notifies the branch taken by an if expression to the tracers which support it
+func (e *Evaluator) traceBranch(exp *ast.IfExpression, taken bool) {
+	if tracer, ok := e.tracer.(BranchTracer); ok && !e.inModule() {
+		tracer.OnBranch(e, exp, taken)
+	}
+}
+
+// Reports if the current frame runs a function of an imported module
+func (e *Evaluator) inModule() bool {
+	return len(e.frames) > 0 && e.frames[len(e.frames)-1].File != ""
+}
+
+// Moves the position of the current frame to the node, like a call to the next frame
+func (e *Evaluator) setPosition(node ast.Node) {
+	if len(e.frames) > 0 {
+		frame := e.frames[len(e.frames)-1]
+		frame.Line, frame.Column = node.Position()
+	}
+}
+
+// Adds the current frame to the stack trace of an error unwinding it
+func (e *Evaluator) traceError(err *objects.ErrorObject, args []objects.Object) {
+	frame := e.frames[len(e.frames)-1]
+	// the functions of the module being evaluated are on its own file
+	file := frame.File
+	if file == e.path {
+		file = ""
+	}
+
+	err.Trace = append(err.Trace, objects.TraceFrame{
+		Function: frame.Function,
+		Args:     summarize(args),
+		File:     file,
+		Line:     frame.Line,
+		Column:   frame.Column,
+	})
+}
+
+// max length of an argument on the stack traces
+const maxArgLength = 20
+
+// Summary of the arguments of a call, like `5, "hola", func`
+func summarize(args []objects.Object) string {
+	summary := make([]string, 0, len(args))
+	for _, arg := range args {
+		var text string
+		switch arg := arg.(type) {
+		case nil:
+			text = "nulo"
+		case *objects.FunctionObject, *objects.BuiltinFunction:
+			text = "func"
+		case *objects.String:
+			text = `"` + arg.Value + `"`
+		default:
+			text = arg.Inspect()
+		}
+
+		if runes := []rune(text); len(runes) > maxArgLength {
+			text = string(runes[:maxArgLength-1]) + "…"
+		}
+		summary = append(summary, text)
+	}
+
+	return strings.Join(summary, ", ")
+}
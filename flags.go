@@ -0,0 +1,171 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/repl"
+	"github.com/sl2.0/tokens"
+)
+
+// exit status for invalid command lines (the same used by the flag package)
+const exitUsage = 2
+
+// Creates the flag set of a subcommand, with an usage message built from its entry on
+// the subcommands list
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		args := ""
+		for _, cmd := range subcommands {
+			if cmd.name == name {
+				args = cmd.usage
+			}
+		}
+		fmt.Fprintf(flags.Output(), "Usage: %s %s %s\n", os.Args[0], name, args)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+func addKeywordsFlag(flags *flag.FlagSet) *string {
+	return flags.String("keywords", tokens.Spanish.Name,
+		"Keyword set: "+strings.Join(tokens.KeywordSetNames(), ", ")+
+			". A '// idioma: <name>' comment on top of the input takes precedence")
+}
+
+func lookupKeywords(name string) (*tokens.KeywordSet, bool) {
+	set, ok := tokens.LookupKeywordSet(name)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Invalid keyword set: "+name)
+	}
+
+	return set, ok
+}
+
+func addAllowEnvFlag(flags *flag.FlagSet) *string {
+	return flags.String("allow-env", "*", "Comma separated list of the environment variables "+
+		"readable by the program. '*' allows every variable, and an empty list none")
+}
+
+// Returns the names of an -allow-env flag. nil allows every variable.
+func allowedEnv(value string) []string {
+	if value == "*" {
+		return nil
+	}
+
+	names := []string{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Registers the flags of the execution limits on the given struct
+func addLimitFlags(flags *flag.FlagSet, limits *evaluator.Limits) {
+	flags.Int64Var(&limits.MaxSteps, "max-steps", 0, "Max number of evaluation steps (0 for unlimited)")
+	flags.Int64Var(&limits.MaxMemory, "max-memory", 0, "Max bytes allocated by strings and lists (0 for unlimited)")
+	flags.Int64Var(&limits.MaxOutput, "max-output", 0, "Max bytes written by imprimir (0 for unlimited)")
+}
+
+func addFormatFlag(flags *flag.FlagSet) *string {
+	return flags.String("format", "text", "Output format: text(default), json")
+}
+
+// Opens the input of a subcommand. An empty path or "-" is the standard input.
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "" || path == "-" {
+		return os.Stdin, nil
+	}
+
+	return os.Open(path)
+}
+
+// Reads a whole input of a subcommand. An empty path or "-" is the standard input.
+func readInput(path string) (string, error) {
+	in, err := openInput(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	content, err := io.ReadAll(in)
+	return string(content), err
+}
+
+// Flags to redirect the output and the errors of a subcommand to files
+type outputFlags struct {
+	out    string
+	err    string
+	append bool
+
+	files []*os.File
+}
+
+func addOutputFlags(flags *flag.FlagSet) *outputFlags {
+	o := &outputFlags{}
+	flags.StringVar(&o.out, "o", "", "File to output the result")
+	flags.StringVar(&o.err, "err", "", "File to output the errors")
+	flags.BoolVar(&o.append, "append", false, "Append to the output files instead of truncating them")
+
+	return o
+}
+
+// Redirects the output of the builder to the files given on the flags, creating them if
+// needed. The files must be closed with close().
+func (o *outputFlags) apply(builder repl.ReplBuilder) (repl.ReplBuilder, error) {
+	if o.out != "" {
+		f, err := o.open(o.out)
+		if err != nil {
+			return builder, fmt.Errorf("Error opening output file: %w", err)
+		}
+		builder = builder.WithStdout(f)
+	}
+
+	if o.err != "" {
+		f, err := o.open(o.err)
+		if err != nil {
+			return builder, fmt.Errorf("Error opening error file: %w", err)
+		}
+		builder = builder.WithStderr(f)
+	}
+
+	return builder, nil
+}
+
+// Opens a file for writing. Using the same path for the output and the errors returns
+// the same file, so they do not overwrite each other.
+func (o *outputFlags) open(path string) (*os.File, error) {
+	for _, f := range o.files {
+		if f.Name() == path {
+			return f, nil
+		}
+	}
+
+	mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if o.append {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+
+	f, err := os.OpenFile(path, mode, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	o.files = append(o.files, f)
+
+	return f, nil
+}
+
+func (o *outputFlags) close() {
+	for _, f := range o.files {
+		f.Close()
+	}
+}
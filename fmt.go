@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sl2.0/format"
+	"github.com/sl2.0/repl"
+)
+
+// The "fmt" subcommand formats programs, printing the result or rewriting the files.
+// Programs with syntax errors are left untouched.
+// Usage: fmt [-w] [-l] [-keywords es] [file|-]...
+func formatFiles(args []string) int {
+	flags := newFlagSet("fmt")
+	write := flags.Bool("w", false, "Write the result to the file instead of printing it")
+	list := flags.Bool("l", false, "List the files whose format differs instead of printing them")
+	keywords := addKeywordsFlag(flags)
+	flags.Parse(args)
+
+	keywordSet, ok := lookupKeywords(*keywords)
+	if !ok {
+		return exitUsage
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	status := repl.EXIT_OK
+	for _, path := range paths {
+		source, err := readInput(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading input file: "+err.Error())
+			status = max(status, repl.EXIT_FAILURE)
+			continue
+		}
+
+		formatted, err := format.Source(source, keywordSet)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:\n%s\n", displayName(path), err.Error())
+			status = repl.EXIT_SYNTAX_ERROR
+			continue
+		}
+
+		switch {
+		case *list:
+			if formatted != source {
+				fmt.Println(displayName(path))
+			}
+		case *write && path != "-":
+			if formatted == source {
+				continue
+			}
+			if err := os.WriteFile(path, []byte(formatted), 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, "Error writing file: "+err.Error())
+				status = max(status, repl.EXIT_FAILURE)
+			}
+		default:
+			fmt.Print(formatted)
+		}
+	}
+
+	return status
+}
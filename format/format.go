@@ -0,0 +1,162 @@
+package format
+
+/*
+Formatter of source code. The layout is rebuilt from the tokens of the input, so
+comments and the keyword set are preserved and no token is added or removed:
+  - blocks are indented with 4 spaces per level
+  - lines which continue an expression (after an operator, '=', ',' or '(') get an
+    extra level of indentation
+  - binary operators are surrounded by one space, unary operators and calls are not
+  - consecutive blank lines are collapsed into one, and trailing blank lines removed
+*/
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/parser"
+	"github.com/sl2.0/tokens"
+)
+
+const indentation = "    "
+
+// Returns the formatted input. Inputs with syntax errors are not formatted, the errors
+// are returned instead (one per line).
+func Source(input string, keywords *tokens.KeywordSet) (string, error) {
+	p := parser.NewParserFromLexer(lexer.NewLexerWithKeywords(input, keywords))
+	p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return "", errors.New(strings.Join(p.Errors(), "\n"))
+	}
+
+	l := lexer.NewLexerWithKeywords(input, keywords)
+	l.KeepComments()
+
+	pr := &printer{}
+	for token := l.NexToken(); token.Type != tokens.EOF; token = l.NexToken() {
+		if token.Type != tokens.LINEBREAK {
+			pr.print(token)
+		}
+	}
+
+	if pr.out.Len() == 0 {
+		return "", nil
+	}
+
+	return pr.out.String() + "\n", nil
+}
+
+type printer struct {
+	out strings.Builder
+
+	depth   int          // number of open braces
+	prev    tokens.Token // last printed token (empty at the start)
+	unary   bool         // the last printed token is an unary operator
+	endLine int          // line where the last printed token ends
+}
+
+func (p *printer) print(token tokens.Token) {
+	text := tokenText(token)
+
+	switch {
+	case p.prev.Type == "":
+		p.writeIndentation(token)
+	case token.Line > p.endLine:
+		p.out.WriteString("\n")
+		if token.Line > p.endLine+1 {
+			p.out.WriteString("\n")
+		}
+		p.writeIndentation(token)
+	case p.needsSpace(token):
+		p.out.WriteString(" ")
+	}
+
+	p.out.WriteString(text)
+
+	switch token.Type {
+	case tokens.LBRAC:
+		p.depth++
+	case tokens.RBRAC:
+		p.depth--
+	}
+
+	p.unary = (token.Type == tokens.MINUS && !endsOperand(p.prev)) || token.Type == tokens.BANG
+	p.prev = token
+	p.endLine = token.Line + strings.Count(text, "\n")
+}
+
+func (p *printer) writeIndentation(token tokens.Token) {
+	depth := p.depth
+	if token.Type == tokens.RBRAC {
+		depth--
+	}
+	if p.prev.Type != "" && continuesLine(p.prev) {
+		depth++
+	}
+
+	p.out.WriteString(strings.Repeat(indentation, max(depth, 0)))
+}
+
+// Reports if a space goes between the last printed token and the given one (which
+// are on the same line)
+func (p *printer) needsSpace(token tokens.Token) bool {
+	switch token.Type {
+	case tokens.SEMICOLON, tokens.COMMA, tokens.COLON, tokens.RPAR, tokens.DOT,
+		tokens.INTERP_MID, tokens.INTERP_END:
+		return false
+	case tokens.LPAR:
+		// calls and function parameters
+		switch p.prev.Type {
+		case tokens.IDENT, tokens.RPAR, tokens.RBRAC, tokens.FUNCTION:
+			return false
+		}
+	}
+
+	switch p.prev.Type {
+	case tokens.LPAR, tokens.DOT, tokens.INTERP_START, tokens.INTERP_MID:
+		return false
+	}
+
+	return !p.unary
+}
+
+// Reports if the token can be the end of an operand, so a following '-' is a binary
+// operator
+func endsOperand(token tokens.Token) bool {
+	switch token.Type {
+	case tokens.IDENT, tokens.NUMBER, tokens.STRING, tokens.TRUE, tokens.FALSE,
+		tokens.RPAR, tokens.RBRAC, tokens.INTERP_END:
+		return true
+	}
+
+	return false
+}
+
+// Reports if a line which ends with the given token continues on the next line
+func continuesLine(token tokens.Token) bool {
+	switch token.Type {
+	case tokens.PLUS, tokens.MINUS, tokens.ASTERISC, tokens.SLASH, tokens.LT, tokens.GT,
+		tokens.EQUALS, tokens.NOTEQUAL, tokens.BANG, tokens.ASIGN, tokens.COMMA, tokens.LPAR:
+		return true
+	}
+
+	return false
+}
+
+// Source text of a token. String literals are the only tokens which do not keep
+// their delimiters on the literal.
+func tokenText(token tokens.Token) string {
+	switch token.Type {
+	case tokens.STRING:
+		return "\"" + token.Literal + "\""
+	case tokens.INTERP_START:
+		return "\"" + token.Literal + "${"
+	case tokens.INTERP_MID:
+		return "}" + token.Literal + "${"
+	case tokens.INTERP_END:
+		return "}" + token.Literal + "\""
+	}
+
+	return token.Literal
+}
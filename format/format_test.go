@@ -0,0 +1,54 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/sl2.0/tokens"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var   x=-5 ;", "var x = -5;\n"},
+		{"var x = 5; // comentario  ", "var x = 5; // comentario\n"},
+		{"// inicio\n\n\n\nvar x = 1;\n\n", "// inicio\n\nvar x = 1;\n"},
+		{"suma(1,2)-!verdadero", "suma(1, 2) - !verdadero\n"},
+		{"var s = \"a ${ suma(1,2) } b ${x}\";", "var s = \"a ${suma(1, 2)} b ${x}\";\n"},
+		{"var y = 1 +\n2;", "var y = 1 +\n    2;\n"},
+		{
+			"func   suma(a:entero,b) {\nsi(a<b){\nretorna a+b;\n} sino {\nretorna -a*(b - 1);\n}\n}",
+			"func suma(a: entero, b) {\n    si (a < b) {\n        retorna a + b;\n    } sino {\n        retorna -a * (b - 1);\n    }\n}\n",
+		},
+		{
+			"// idioma: en\nfunc f() {\nreturn func(x) {\nreturn x;\n};\n}",
+			"// idioma: en\nfunc f() {\n    return func(x) {\n        return x;\n    };\n}\n",
+		},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		formatted, err := Source(tt.input, tokens.Spanish)
+		if err != nil {
+			t.Fatalf("unexpected error formatting %q: %v", tt.input, err)
+		}
+
+		if formatted != tt.expected {
+			t.Errorf("wrong format for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, formatted)
+		}
+
+		// formatting is idempotent
+		again, _ := Source(formatted, tokens.Spanish)
+		if again != formatted {
+			t.Errorf("format is not idempotent for %q. got=%q", formatted, again)
+		}
+	}
+}
+
+func TestSourceWithSyntaxErrors(t *testing.T) {
+	_, err := Source("var x = ;", tokens.Spanish)
+	if err == nil {
+		t.Fatalf("expected a syntax error")
+	}
+}
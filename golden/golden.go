@@ -0,0 +1,220 @@
+package golden
+
+/*
+Golden files check the interpreter with whole programs. Every program (a ".sl" file) is
+executed like the "run" subcommand does, and its output and errors are compared with the
+ones expected, kept next to it on files with the same name and the extensions ".out"
+and ".err":
+
+	testdata/
+	    recursion.sl
+	    recursion.out
+	    sintaxis.sl
+	    sintaxis.err
+
+A missing expectation file expects no output (or no errors), but every program needs at
+least one of them. The files without them, like the modules imported by the programs,
+are not taken as programs when searching directories. The expectation files are written with the update mode, which
+records the current output and errors of the programs.
+*/
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sl2.0/repl"
+	"github.com/sl2.0/testrunner"
+	"github.com/sl2.0/tokens"
+)
+
+const (
+	ProgramExt = ".sl"
+	OutputExt  = ".out"
+	ErrorsExt  = ".err"
+)
+
+// Outcome of a program compared with its expectation files
+type Result struct {
+	Path string
+
+	Output string
+	Errors string
+
+	ExpectedOutput string
+	ExpectedErrors string
+
+	Missing bool // the program has no expectation files
+	Timeout bool // the program did not end before the max time
+}
+
+func (r *Result) Passed() bool {
+	return !r.Missing && !r.Timeout && r.Output == r.ExpectedOutput && r.Errors == r.ExpectedErrors
+}
+
+// Returned by the runs of programs which do not end before the max time
+var ErrTimeout = errors.New("the program did not end before the max time")
+
+type Runner struct {
+	Keywords *tokens.KeywordSet // set of the programs without a pragma
+	MaxTime  int64              // max time of every program in milliseconds
+}
+
+func NewRunner() *Runner {
+	return &Runner{Keywords: tokens.Spanish, MaxTime: 40000}
+}
+
+// Returns the programs on the paths, sorted. Directories are searched recursively for
+// programs with an expectation file, so the modules they import and the files with
+// tests are skipped. Files are always included, which records new programs on update.
+func Discover(paths []string) ([]string, error) {
+	return testrunner.FindFiles(paths, func(file string) bool {
+		if filepath.Ext(file) != ProgramExt || strings.HasSuffix(file, testrunner.FileSuffix) {
+			return false
+		}
+		return exists(goldenPath(file, OutputExt)) || exists(goldenPath(file, ErrorsExt))
+	})
+}
+
+// Executes the program, returning its output and errors. Programs which do not end
+// before the max time are stopped, returning what they wrote and ErrTimeout.
+func (r *Runner) Run(path string) (string, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.MaxTime)*time.Millisecond)
+	defer cancel()
+
+	var output, errOutput bytes.Buffer
+	repl.NewReplBuilder().
+		WithMode(repl.EVAL).
+		WithKeywords(r.Keywords).
+		WithContext(ctx).
+		WithFile(path).
+		WithStdin(f).
+		WithStdout(nopWriteCloser{&output}).
+		WithStderr(nopWriteCloser{&errOutput}).
+		Build().
+		Run()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return output.String(), errOutput.String(), fmt.Errorf("%s: %w", path, ErrTimeout)
+	}
+
+	return output.String(), errOutput.String(), nil
+}
+
+// Executes the program and compares it with its expectation files
+func (r *Runner) Check(path string) (*Result, error) {
+	result := &Result{Path: path}
+
+	var err error
+	result.Output, result.Errors, err = r.Run(path)
+	if errors.Is(err, ErrTimeout) {
+		result.Timeout = true
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	outputFound, errorsFound := true, true
+	if result.ExpectedOutput, err = readExpectation(goldenPath(path, OutputExt)); os.IsNotExist(err) {
+		outputFound = false
+	} else if err != nil {
+		return nil, err
+	}
+	if result.ExpectedErrors, err = readExpectation(goldenPath(path, ErrorsExt)); os.IsNotExist(err) {
+		errorsFound = false
+	} else if err != nil {
+		return nil, err
+	}
+
+	result.Missing = !outputFound && !errorsFound
+
+	return result, nil
+}
+
+// Executes the program and writes its expectation files. Empty ones are removed,
+// unless both are empty (the output file is kept to mark the program as checked). The
+// files of programs which do not end before the max time are not written.
+func (r *Runner) Update(path string) error {
+	output, errors, err := r.Run(path)
+	if err != nil {
+		return err
+	}
+
+	if err := writeExpectation(goldenPath(path, OutputExt), output, errors == ""); err != nil {
+		return err
+	}
+
+	return writeExpectation(goldenPath(path, ErrorsExt), errors, false)
+}
+
+// Writes the differences of a failed result
+func (r *Result) WriteReport(w io.Writer) {
+	if r.Timeout {
+		fmt.Fprintf(w, "%s: %s\n", r.Path, ErrTimeout)
+		return
+	}
+
+	if r.Missing {
+		fmt.Fprintf(w, "%s: missing the %s and %s files (run with -update to create them)\n",
+			r.Path, OutputExt, ErrorsExt)
+		return
+	}
+
+	fmt.Fprintf(w, "%s differs:\n", r.Path)
+	if r.Output != r.ExpectedOutput {
+		fmt.Fprintf(w, "  expected output:\n%s\n  got:\n%s\n", indent(r.ExpectedOutput), indent(r.Output))
+	}
+	if r.Errors != r.ExpectedErrors {
+		fmt.Fprintf(w, "  expected errors:\n%s\n  got:\n%s\n", indent(r.ExpectedErrors), indent(r.Errors))
+	}
+}
+
+func goldenPath(program, ext string) string {
+	return strings.TrimSuffix(program, ProgramExt) + ext
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func readExpectation(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	return string(content), err
+}
+
+func writeExpectation(path, content string, keepEmpty bool) error {
+	if content == "" && !keepEmpty {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	return os.WriteFile(path, []byte(content), 0o644)
+}
+
+func indent(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	return "    " + strings.Join(lines, "\n    ")
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
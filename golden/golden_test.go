@@ -0,0 +1,190 @@
+package golden
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Rewrite the expectation files of testdata")
+
+func TestGoldenFiles(t *testing.T) {
+	programs, err := Discover([]string{"testdata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(programs) == 0 {
+		t.Fatal("No programs found on testdata")
+	}
+
+	runner := NewRunner()
+	for _, program := range programs {
+		t.Run(filepath.Base(program), func(t *testing.T) {
+			if *update {
+				if err := runner.Update(program); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := runner.Check(program)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !result.Passed() {
+				var report strings.Builder
+				result.WriteReport(&report)
+				t.Error(report.String())
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	program := filepath.Join(dir, "hola.sl")
+	writeFile(t, program, `imprimir("hola")`)
+
+	runner := NewRunner()
+
+	result, err := runner.Check(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Missing || result.Passed() {
+		t.Fatalf("Expected a missing result. Got %+v", result)
+	}
+
+	writeFile(t, filepath.Join(dir, "hola.out"), "adios\n")
+	result, err = runner.Check(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Passed() {
+		t.Fatalf("Expected a failed result. Got %+v", result)
+	}
+
+	var report strings.Builder
+	result.WriteReport(&report)
+	expected := program + " differs:\n" +
+		"  expected output:\n    adios\n" +
+		"  got:\n    hola\n    No returned values\n"
+	if report.String() != expected {
+		t.Fatalf("Expected report:\n%s\nGot:\n%s", expected, report.String())
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir := t.TempDir()
+	program := filepath.Join(dir, "sintaxis.sl")
+	writeFile(t, program, "var x = (1\n")
+	writeFile(t, filepath.Join(dir, "sintaxis.out"), "old\n")
+
+	runner := NewRunner()
+	if err := runner.Update(program); err != nil {
+		t.Fatal(err)
+	}
+
+	// the program only writes errors, so the output file is removed
+	if _, err := os.Stat(filepath.Join(dir, "sintaxis.out")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the output file to be removed. Got %v", err)
+	}
+
+	errors, err := os.ReadFile(filepath.Join(dir, "sintaxis.err"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errors) == 0 {
+		t.Fatal("Expected the errors of the program")
+	}
+
+	result, err := runner.Check(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Passed() {
+		t.Fatalf("Expected the updated program to pass. Got %+v", result)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	dir := t.TempDir()
+	slow := filepath.Join(dir, "a_lento.sl")
+	writeFile(t, slow, "repetir 1000000000 { 1 }\n")
+	writeFile(t, filepath.Join(dir, "a_lento.out"), "1\n")
+	fast := filepath.Join(dir, "b.sl")
+	writeFile(t, fast, "1\n")
+	writeFile(t, filepath.Join(dir, "b.out"), "1\n")
+
+	runner := NewRunner()
+	runner.MaxTime = 100
+
+	// the slow program fails, and the next ones are still checked
+	result, err := runner.Check(slow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Timeout || result.Passed() {
+		t.Fatalf("Expected a timeout. Got %+v", result)
+	}
+
+	var report strings.Builder
+	result.WriteReport(&report)
+	expected := slow + ": the program did not end before the max time\n"
+	if report.String() != expected {
+		t.Fatalf("Expected report:\n%s\nGot:\n%s", expected, report.String())
+	}
+
+	result, err = runner.Check(fast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Passed() {
+		t.Fatalf("Expected the fast program to pass. Got %+v", result)
+	}
+
+	// the expectation files are not updated with the output of a stopped program
+	if err := runner.Update(slow); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected a timeout updating the program. Got %v", err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "b.sl"), "")
+	writeFile(t, filepath.Join(dir, "b.out"), "")
+	writeFile(t, filepath.Join(dir, "sub", "a.sl"), "")
+	writeFile(t, filepath.Join(dir, "sub", "a.err"), "")
+	// modules and tests have no expectation files, and tests are skipped anyway
+	writeFile(t, filepath.Join(dir, "util.sl"), "")
+	writeFile(t, filepath.Join(dir, "util_prueba.sl"), "")
+	writeFile(t, filepath.Join(dir, "util_prueba.out"), "")
+	writeFile(t, filepath.Join(dir, "nuevo", "c.sl"), "")
+
+	programs, err := Discover([]string{dir, filepath.Join(dir, "nuevo", "c.sl")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		filepath.Join(dir, "b.sl"),
+		filepath.Join(dir, "nuevo", "c.sl"),
+		filepath.Join(dir, "sub", "a.sl"),
+	}
+	if strings.Join(programs, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected programs %v. Got %v", expected, programs)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/repl"
+)
+
+// The "repl" subcommand starts the interactive mode. When the standard input is not a
+// terminal (like a pipe) its whole content is evaluated instead.
+// Usage: repl [-keywords es] [-max-time 40000] [-quiet] [-history file] [-transcript file] [-o file] [-err file] [-append]
+func interactive(args []string) int {
+	const colorMagenta = "\033[35m"
+	const colorNone = "\033[0m"
+
+	flags := newFlagSet("repl")
+	keywords := addKeywordsFlag(flags)
+	maxTime := flags.Int64("max-time", 40000, "Max time for execution")
+	allowEnv := addAllowEnvFlag(flags)
+	limits := evaluator.Limits{}
+	addLimitFlags(flags, &limits)
+	quiet := flags.Bool("quiet", false, "Suppres unnecesary messages")
+	historyFile := flags.String("history", defaultHistoryFile(), "File to keep the history of the interactive mode (empty to disable)")
+	transcriptFile := flags.String("transcript", "", "File to record every input, output and error of the session (see the replay subcommand)")
+	outputs := addOutputFlags(flags)
+	flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	keywordSet, ok := lookupKeywords(*keywords)
+	if !ok {
+		return exitUsage
+	}
+
+	builder := repl.NewReplBuilder().
+		WithMode(repl.EVAL).
+		WithKeywords(keywordSet).
+		WithTimeout(*maxTime).
+		WithAllowedEnv(allowedEnv(*allowEnv)).
+		WithLimits(limits).
+		WithHistoryFile(*historyFile)
+
+	// Check if data is being piped into Stdin
+	stat, _ := os.Stdin.Stat()
+	piped := (stat.Mode() & os.ModeCharDevice) == 0
+	if !piped {
+		builder = builder.Interactive()
+	}
+
+	if *transcriptFile != "" {
+		f, err := outputs.open(*transcriptFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error opening transcript file: "+err.Error())
+			return repl.EXIT_FAILURE
+		}
+		builder = builder.WithTranscript(f)
+	}
+
+	builder, err := outputs.apply(builder)
+	defer outputs.close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return repl.EXIT_FAILURE
+	}
+
+	replInstance := builder.Build()
+
+	// On quiet mode (or without a terminal) this lines are not printed
+	if !*quiet && !piped {
+		fmt.Printf("Starting REPL in %seval%s mode...\n", colorMagenta, colorNone)
+	}
+
+	return replInstance.Run()
+}
+
+// history is kept on the home directory of the user, if it is available
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".sl_history")
+}
@@ -0,0 +1,403 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/sl2.0/objects"
+)
+
+var (
+	anyType    = reflect.TypeOf((*any)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	objectType = reflect.TypeOf((*objects.Object)(nil)).Elem()
+
+	// type of the functions of the language when the Go type is free
+	funcType = reflect.TypeOf((func(...any) (any, error))(nil))
+)
+
+// Converts a Go value to an object. Invalid values and nil pointers are nil (no value).
+func (i *Interpreter) toObject(v reflect.Value) (objects.Object, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if v.Type().Implements(objectType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return nil, nil
+		}
+		return v.Interface().(objects.Object), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return i.toObject(v.Elem())
+
+	case reflect.Bool:
+		return &objects.Boolean{Value: v.Bool()}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &objects.Integer{Value: v.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows the integers of the language", v.Uint())
+		}
+		return &objects.Integer{Value: int64(v.Uint())}, nil
+
+	case reflect.String:
+		return &objects.String{Value: v.String()}, nil
+
+	case reflect.Slice, reflect.Array:
+		elements := make([]objects.Object, 0, v.Len())
+		for n := 0; n < v.Len(); n++ {
+			element, err := i.toValueObject(v.Index(n))
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", n, err)
+			}
+			elements = append(elements, element)
+		}
+		return &objects.Array{Elements: elements}, nil
+
+	case reflect.Map:
+		pairs := make([]objects.MapPair, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := i.toValueObject(iter.Key())
+			if err != nil {
+				return nil, fmt.Errorf("map key: %w", err)
+			}
+
+			value, err := i.toValueObject(iter.Value())
+			if err != nil {
+				return nil, fmt.Errorf("value of %s: %w", key.Inspect(), err)
+			}
+
+			pairs = append(pairs, objects.MapPair{Key: key, Value: value})
+		}
+
+		// go maps are not ordered
+		sort.Slice(pairs, func(a, b int) bool {
+			return pairs[a].Key.Inspect() < pairs[b].Key.Inspect()
+		})
+		return &objects.Map{Pairs: pairs}, nil
+
+	case reflect.Func:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return i.wrapFunc(v), nil
+	}
+
+	return nil, fmt.Errorf("cannot convert values of type %s", v.Type())
+}
+
+// Same as toObject, for elements of lists and maps (which must have a value)
+func (i *Interpreter) toValueObject(v reflect.Value) (objects.Object, error) {
+	obj, err := i.toObject(v)
+	if err == nil && obj == nil {
+		err = fmt.Errorf("nil values cannot be part of lists or maps")
+	}
+
+	return obj, err
+}
+
+// Converts an object to a Go value with the natural type of the object
+func (i *Interpreter) goValue(obj objects.Object) (any, error) {
+	if obj == nil {
+		return nil, nil
+	}
+
+	v, err := i.toGo(obj, anyType)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Interface(), nil
+}
+
+// Converts an object to a Go value of the given type. The "any" type converts the
+// object to its natural type.
+func (i *Interpreter) toGo(obj objects.Object, t reflect.Type) (reflect.Value, error) {
+	if obj == nil {
+		return reflect.Zero(t), nil
+	}
+
+	if t == anyType {
+		natural, err := naturalType(obj)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		v, err := i.toGo(obj, natural)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		// wrapped, so it can be stored on collections of any
+		wrapped := reflect.New(anyType).Elem()
+		wrapped.Set(v)
+		return wrapped, nil
+	}
+
+	if reflect.TypeOf(obj).AssignableTo(t) {
+		return reflect.ValueOf(obj), nil
+	}
+
+	v := reflect.New(t).Elem()
+
+	switch obj := obj.(type) {
+	case *objects.Integer:
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.OverflowInt(obj.Value) {
+				return v, fmt.Errorf("%d overflows %s", obj.Value, t)
+			}
+			v.SetInt(obj.Value)
+			return v, nil
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if obj.Value < 0 || v.OverflowUint(uint64(obj.Value)) {
+				return v, fmt.Errorf("%d overflows %s", obj.Value, t)
+			}
+			v.SetUint(uint64(obj.Value))
+			return v, nil
+
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(obj.Value))
+			return v, nil
+		}
+
+	case *objects.Boolean:
+		if t.Kind() == reflect.Bool {
+			v.SetBool(obj.Value)
+			return v, nil
+		}
+
+	case *objects.String:
+		if t.Kind() == reflect.String {
+			v.SetString(obj.Value)
+			return v, nil
+		}
+
+	case *objects.Array:
+		switch t.Kind() {
+		case reflect.Slice:
+			v = reflect.MakeSlice(t, len(obj.Elements), len(obj.Elements))
+		case reflect.Array:
+			if t.Len() != len(obj.Elements) {
+				return v, fmt.Errorf("cannot convert a list of %d elements to %s", len(obj.Elements), t)
+			}
+		default:
+			return v, convertError(obj, t)
+		}
+
+		for n, element := range obj.Elements {
+			converted, err := i.toGo(element, t.Elem())
+			if err != nil {
+				return v, fmt.Errorf("element %d: %w", n, err)
+			}
+			v.Index(n).Set(converted)
+		}
+		return v, nil
+
+	case *objects.Map:
+		if t.Kind() != reflect.Map {
+			return v, convertError(obj, t)
+		}
+
+		v = reflect.MakeMapWithSize(t, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			key, err := i.toGo(pair.Key, t.Key())
+			if err != nil {
+				return v, fmt.Errorf("map key: %w", err)
+			}
+
+			value, err := i.toGo(pair.Value, t.Elem())
+			if err != nil {
+				return v, fmt.Errorf("value of %s: %w", pair.Key.Inspect(), err)
+			}
+
+			v.SetMapIndex(key, value)
+		}
+		return v, nil
+
+	case *objects.FunctionObject, *objects.BuiltinFunction:
+		if t.Kind() == reflect.Func {
+			return i.makeFunc(obj, t), nil
+		}
+	}
+
+	return v, convertError(obj, t)
+}
+
+func convertError(obj objects.Object, t reflect.Type) error {
+	return fmt.Errorf("cannot convert %s to %s", obj.Type(), t)
+}
+
+// Go type used for an object when the type is free
+func naturalType(obj objects.Object) (reflect.Type, error) {
+	switch obj := obj.(type) {
+	case *objects.Integer:
+		return reflect.TypeOf(int64(0)), nil
+	case *objects.Boolean:
+		return reflect.TypeOf(false), nil
+	case *objects.String:
+		return reflect.TypeOf(""), nil
+	case *objects.Array:
+		return reflect.TypeOf([]any{}), nil
+	case *objects.Map:
+		for _, pair := range obj.Pairs {
+			if _, ok := pair.Key.(*objects.String); !ok {
+				return reflect.TypeOf(map[any]any{}), nil
+			}
+		}
+		return reflect.TypeOf(map[string]any{}), nil
+	case *objects.FunctionObject, *objects.BuiltinFunction:
+		return funcType, nil
+	}
+
+	return nil, fmt.Errorf("cannot convert %s to a Go value", obj.Type())
+}
+
+// Wraps a Go function as a builtin. The arguments are converted to the types of the
+// parameters, and the returned error (or a panic) is an error of the language.
+func (i *Interpreter) wrapFunc(fn reflect.Value) *objects.BuiltinFunction {
+	t := fn.Type()
+	builtin := &objects.BuiltinFunction{Name: t.String()}
+
+	builtin.Fn = func(args ...objects.Object) (result objects.Object) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = objects.NewError("%s: %v", builtin.Name, r)
+			}
+		}()
+
+		in, err := i.funcArgs(t, args)
+		if err != nil {
+			return objects.NewError("%s: %s", builtin.Name, err)
+		}
+
+		out := fn.Call(in)
+
+		// the error is always the last returned value
+		if n := len(out); n > 0 && t.Out(n-1) == errorType {
+			if !out[n-1].IsNil() {
+				return objects.NewError("%s: %s", builtin.Name, out[n-1].Interface().(error))
+			}
+			out = out[:n-1]
+		}
+
+		switch len(out) {
+		case 0:
+			return nil
+		case 1:
+			result, err = i.toObject(out[0])
+		default:
+			result, err = i.toObject(reflect.ValueOf(valuesOf(out)))
+		}
+		if err != nil {
+			return objects.NewError("%s: returned value: %s", builtin.Name, err)
+		}
+
+		return result
+	}
+
+	return builtin
+}
+
+// Converts the arguments of a call to the parameters of a Go function
+func (i *Interpreter) funcArgs(t reflect.Type, args []objects.Object) ([]reflect.Value, error) {
+	n := t.NumIn()
+	if t.IsVariadic() && len(args) < n-1 {
+		return nil, fmt.Errorf("expected at least %d arguments. Got %d", n-1, len(args))
+	}
+	if !t.IsVariadic() && len(args) != n {
+		return nil, fmt.Errorf("expected %d arguments. Got %d", n, len(args))
+	}
+
+	in := make([]reflect.Value, 0, len(args))
+	for k, arg := range args {
+		var param reflect.Type
+		if t.IsVariadic() && k >= n-1 {
+			param = t.In(n - 1).Elem()
+		} else {
+			param = t.In(k)
+		}
+
+		v, err := i.toGo(arg, param)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", k+1, err)
+		}
+		in = append(in, v)
+	}
+
+	return in, nil
+}
+
+// Makes a Go function of the given type which calls a function of the language. If the
+// type has no error result, failures panic.
+func (i *Interpreter) makeFunc(callee objects.Object, t reflect.Type) reflect.Value {
+	return reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		out := make([]reflect.Value, t.NumOut())
+		for n := range out {
+			out[n] = reflect.Zero(t.Out(n))
+		}
+
+		fail := func(err error) []reflect.Value {
+			if len(out) == 0 || t.Out(len(out)-1) != errorType {
+				panic(err)
+			}
+
+			out[len(out)-1] = reflect.ValueOf(&err).Elem()
+			return out
+		}
+
+		// variadic arguments are received as a slice
+		if t.IsVariadic() {
+			last := in[len(in)-1]
+			in = in[:len(in)-1]
+			for n := 0; n < last.Len(); n++ {
+				in = append(in, last.Index(n))
+			}
+		}
+
+		args := make([]objects.Object, 0, len(in))
+		for n, v := range in {
+			arg, err := i.toObject(v)
+			if err != nil {
+				return fail(fmt.Errorf("argument %d: %w", n+1, err))
+			}
+			args = append(args, arg)
+		}
+
+		result, err := i.call(i.context(), callee, args)
+		if err != nil {
+			return fail(err)
+		}
+
+		if len(out) > 0 && t.Out(0) != errorType {
+			v, err := i.toGo(result, t.Out(0))
+			if err != nil {
+				return fail(fmt.Errorf("returned value: %w", err))
+			}
+			out[0] = v
+		}
+
+		return out
+	})
+}
+
+func valuesOf(values []reflect.Value) []any {
+	list := make([]any, 0, len(values))
+	for _, v := range values {
+		list = append(list, v.Interface())
+	}
+
+	return list
+}
@@ -0,0 +1,241 @@
+package interpreter
+
+/*
+Interpreter embeds the language on Go programs. Go values are converted to the objects
+of the language (and back) using reflection:
+
+	Go                          language
+	int, int8..int64, uint..    entero (uint values over the int64 range are an error)
+	string                      cadena
+	bool                        true, false
+	slices and arrays           lists
+	maps                        maps (sorted by key)
+	funcs                       builtin functions
+
+Go functions can return a value, an error or both. A returned error (or a panic) is a
+runtime error on the program. Functions of the language are converted to Go functions
+of the requested type, or to func(...any) (any, error) when the type is free.
+
+Values returned to Go without a requested type use int64, string, bool, []any,
+map[string]any (map[any]any if some key is not a string) and func(...any) (any, error).
+
+An Interpreter keeps its variables between calls. It is not safe for concurrent use.
+
+	interp := interpreter.NewInterpreter()
+	interp.Set("doble", func(n int) int { return n * 2 })
+	value, err := interp.Run(ctx, "var x = doble(21); x")
+*/
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/sl2.0/ast"
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/objects"
+	"github.com/sl2.0/parser"
+	"github.com/sl2.0/tokens"
+)
+
+type Interpreter struct {
+	env      *objects.Storage
+	keywords *tokens.KeywordSet
+	limits   evaluator.Limits
+	output   io.Writer
+	modules  *evaluator.Modules // imported by the sources (created on the first run)
+
+	// context of the running evaluation, used when Go calls functions of the language
+	// while a program is running
+	ctx context.Context
+}
+
+// Returned when the source has lexical or syntax errors
+type SyntaxError struct {
+	Errors []string
+}
+
+func (e *SyntaxError) Error() string {
+	return strings.Join(e.Errors, "\n")
+}
+
+// Returned when the evaluation fails. Exceeded limits have the kind LIMIT_ERROR.
+type RuntimeError struct {
+	Message string
+	Kind    objects.ErrorKind
+	Trace   []objects.TraceFrame // calls unwound by the error, from the innermost one
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Message
+}
+
+// Returned when the program ends itself with "salir"
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func NewInterpreter() *Interpreter {
+	return &Interpreter{
+		env:      objects.NewStorage(),
+		keywords: tokens.Spanish,
+		output:   os.Stdout,
+	}
+}
+
+// Keyword set used when the source does not select one with a pragma
+func (i *Interpreter) SetKeywords(keywords *tokens.KeywordSet) {
+	i.keywords = keywords
+}
+
+// Limits of the resources used by every call to Run or Call (zero values disable a
+// limit)
+func (i *Interpreter) SetLimits(limits evaluator.Limits) {
+	i.limits = limits
+}
+
+// Sets the writer of the print builtins (the standard output by default)
+func (i *Interpreter) SetOutput(output io.Writer) {
+	i.output = output
+}
+
+// Runs the source, returning the value of its last statement (nil if it has no value).
+// The variables and functions defined by the source are kept for later calls. The
+// evaluation stops with the error of the context when it is done.
+func (i *Interpreter) Run(ctx context.Context, src string) (any, error) {
+	p := parser.NewParserFromLexer(lexer.NewLexerWithKeywords(src, i.keywords))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &SyntaxError{Errors: p.Errors()}
+	}
+
+	defer i.enter(ctx)()
+
+	ev := i.newEvaluator(ctx, program)
+	result, err := i.check(ctx, ev, ev.EvalProgram(i.env))
+	if err != nil {
+		return nil, err
+	}
+
+	return i.goValue(result)
+}
+
+// Sets a variable, converting the Go value to the language
+func (i *Interpreter) Set(name string, value any) error {
+	obj, err := i.toObject(reflect.ValueOf(value))
+	if err != nil {
+		return fmt.Errorf("cannot set '%s': %w", name, err)
+	}
+
+	if fn, ok := obj.(*objects.BuiltinFunction); ok {
+		fn.Name = name
+	}
+
+	i.env.Set(name, obj)
+
+	return nil
+}
+
+// Returns the value of a variable converted to Go
+func (i *Interpreter) Get(name string) (any, error) {
+	obj, ok := i.env.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("undefined variable '%s'", name)
+	}
+
+	return i.goValue(obj)
+}
+
+// Calls a function defined on the interpreter, converting the arguments and the
+// returned value
+func (i *Interpreter) Call(ctx context.Context, fnName string, args ...any) (any, error) {
+	fn, ok := i.env.Get(fnName)
+	if !ok {
+		return nil, fmt.Errorf("undefined function '%s'", fnName)
+	}
+
+	objs := make([]objects.Object, 0, len(args))
+	for n, arg := range args {
+		obj, err := i.toObject(reflect.ValueOf(arg))
+		if err != nil {
+			return nil, fmt.Errorf("argument %d of '%s': %w", n+1, fnName, err)
+		}
+		objs = append(objs, obj)
+	}
+
+	defer i.enter(ctx)()
+
+	result, err := i.call(ctx, fn, objs)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.goValue(result)
+}
+
+// Calls a function of the language with converted arguments
+func (i *Interpreter) call(ctx context.Context, fn objects.Object, args []objects.Object) (objects.Object, error) {
+	ev := i.newEvaluator(ctx, &ast.Program{})
+	return i.check(ctx, ev, ev.CallFunction(fn, i.env, args...))
+}
+
+func (i *Interpreter) newEvaluator(ctx context.Context, program *ast.Program) *evaluator.Evaluator {
+	ev := evaluator.NewFromProgram(program)
+	ev.SetContext(ctx)
+	ev.SetBudget(evaluator.NewBudget(i.limits))
+	ev.SetOutput(i.output)
+
+	if i.modules == nil {
+		i.modules = evaluator.NewModules(i.keywords)
+	}
+	ev.SetModules(i.modules)
+
+	return ev
+}
+
+// Converts the failures of an evaluation to Go errors
+func (i *Interpreter) check(ctx context.Context, ev *evaluator.Evaluator, result objects.Object) (objects.Object, error) {
+	if ev.HasErrors() {
+		return nil, &RuntimeError{Message: strings.Join(ev.Errors(), "\n"), Kind: objects.RUNTIME_ERROR}
+	}
+
+	switch result := result.(type) {
+	case *objects.ErrorObject:
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &RuntimeError{Message: result.Inspect(), Kind: result.Kind, Trace: result.Trace}
+	case *objects.ExitObject:
+		return nil, &ExitError{Code: int(result.Code)}
+	}
+
+	return result, nil
+}
+
+// Sets the context of the running evaluation. Returns the function which restores the
+// previous one.
+func (i *Interpreter) enter(ctx context.Context) func() {
+	previous := i.ctx
+	i.ctx = ctx
+
+	return func() {
+		i.ctx = previous
+	}
+}
+
+// context for functions of the language called from Go
+func (i *Interpreter) context() context.Context {
+	if i.ctx == nil {
+		return context.Background()
+	}
+
+	return i.ctx
+}
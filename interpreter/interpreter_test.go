@@ -0,0 +1,220 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/objects"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		src      string
+		expected any
+	}{
+		{src: "2 * 21", expected: int64(42)},
+		{src: `"hola " + "mundo"`, expected: "hola mundo"},
+		{src: "1 < 2", expected: true},
+		{src: "var x = 5; x", expected: int64(5)},
+		{src: "argumentos()", expected: []any{}},
+	}
+
+	for _, tc := range testCases {
+		value, err := NewInterpreter().Run(context.Background(), tc.src)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.src, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(value, tc.expected) {
+			t.Errorf("%s: expected %#v. Got %#v", tc.src, tc.expected, value)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	interp := NewInterpreter()
+
+	_, err := interp.Run(context.Background(), "var x = ;")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("expected a syntax error. Got %v", err)
+	}
+
+	_, err = interp.Run(context.Background(), "2 * true")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Errorf("expected a runtime error. Got %v", err)
+	}
+
+	_, err = interp.Run(context.Background(), "salir(3); 1")
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Errorf("expected an exit with status 3. Got %v", err)
+	}
+}
+
+func TestRunWithCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := NewInterpreter().Run(ctx, "repetir 1000000000 { }")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error. Got %v", err)
+	}
+}
+
+func TestSetAndGet(t *testing.T) {
+	testCases := []struct {
+		value    any
+		expected any
+	}{
+		{value: 7, expected: int64(7)},
+		{value: uint8(7), expected: int64(7)},
+		{value: "texto", expected: "texto"},
+		{value: true, expected: true},
+		{value: []int{1, 2}, expected: []any{int64(1), int64(2)}},
+		{value: [2]string{"a", "b"}, expected: []any{"a", "b"}},
+		{value: map[string]int{"b": 2, "a": 1}, expected: map[string]any{"a": int64(1), "b": int64(2)}},
+		{value: map[int]bool{1: true}, expected: map[any]any{int64(1): true}},
+		{value: nil, expected: nil},
+	}
+
+	for _, tc := range testCases {
+		interp := NewInterpreter()
+		if err := interp.Set("valor", tc.value); err != nil {
+			t.Errorf("%#v: unexpected error: %v", tc.value, err)
+			continue
+		}
+
+		value, err := interp.Get("valor")
+		if err != nil {
+			t.Errorf("%#v: unexpected error: %v", tc.value, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(value, tc.expected) {
+			t.Errorf("%#v: expected %#v. Got %#v", tc.value, tc.expected, value)
+		}
+	}
+
+	interp := NewInterpreter()
+	if err := interp.Set("x", struct{}{}); err == nil {
+		t.Errorf("expected an error setting a struct")
+	}
+	if err := interp.Set("x", []any{nil}); err == nil {
+		t.Errorf("expected an error setting a list with nil values")
+	}
+	if _, err := interp.Get("indefinida"); err == nil {
+		t.Errorf("expected an error getting an undefined variable")
+	}
+}
+
+func TestGoFunctions(t *testing.T) {
+	interp := NewInterpreter()
+	interp.Set("doble", func(n int) int { return n * 2 })
+	interp.Set("unir", func(sep string, parts ...string) string { return strings.Join(parts, sep) })
+	interp.Set("dividir", func(a, b int) (int, error) {
+		if b == 0 {
+			return 0, fmt.Errorf("division por cero")
+		}
+		return a / b, nil
+	})
+	interp.Set("falla", func() { panic("algo salio mal") })
+
+	testCases := []struct {
+		src      string
+		expected any
+		err      string
+	}{
+		{src: "doble(21)", expected: int64(42)},
+		{src: `unir("-", "a", "b", "c")`, expected: "a-b-c"},
+		{src: "dividir(9, 3)", expected: int64(3)},
+		{src: "dividir(1, 0)", err: "dividir: division por cero"},
+		{src: "doble(1, 2)", err: "doble: expected 1 arguments. Got 2"},
+		{src: `doble("a")`, err: "doble: argument 1: cannot convert STRING to int"},
+		{src: "falla()", err: "falla: algo salio mal"},
+	}
+
+	for _, tc := range testCases {
+		value, err := interp.Run(context.Background(), tc.src)
+
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%s: expected error '%s'. Got %v", tc.src, tc.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.src, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(value, tc.expected) {
+			t.Errorf("%s: expected %#v. Got %#v", tc.src, tc.expected, value)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	interp := NewInterpreter()
+	_, err := interp.Run(context.Background(), "func suma(a, b) { retorna a + b; }")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	value, err := interp.Call(context.Background(), "suma", 2, 3)
+	if err != nil || value != int64(5) {
+		t.Errorf("expected 5. Got %#v (%v)", value, err)
+	}
+
+	if _, err := interp.Call(context.Background(), "suma", 1); err == nil {
+		t.Errorf("expected an error calling with missing arguments")
+	}
+
+	if _, err := interp.Call(context.Background(), "resta", 1, 2); err == nil {
+		t.Errorf("expected an error calling an undefined function")
+	}
+
+	// functions of the language converted to Go functions
+	fn, _ := interp.Get("suma")
+	value, err = fn.(func(...any) (any, error))(4, 5)
+	if err != nil || value != int64(9) {
+		t.Errorf("expected 9. Got %#v (%v)", value, err)
+	}
+
+	// and received by Go functions with a typed parameter
+	interp.Set("aplicar", func(f func(int, int) int) int { return f(10, 20) })
+	value, err = interp.Run(context.Background(), "aplicar(suma)")
+	if err != nil || value != int64(30) {
+		t.Errorf("expected 30. Got %#v (%v)", value, err)
+	}
+}
+
+func TestLimitsAndOutput(t *testing.T) {
+	var out strings.Builder
+
+	interp := NewInterpreter()
+	interp.SetOutput(&out)
+	interp.SetLimits(evaluator.Limits{MaxSteps: 100})
+
+	if _, err := interp.Run(context.Background(), `imprimir("hola", 2)`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "hola 2\n" {
+		t.Errorf("expected output 'hola 2'. Got %q", out.String())
+	}
+
+	_, err := interp.Run(context.Background(), "repetir 1000 { 1 }")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Kind != objects.LIMIT_ERROR {
+		t.Errorf("expected a limit error. Got %v", err)
+	}
+}
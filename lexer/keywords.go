@@ -0,0 +1,113 @@
+package lexer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sl2.0/tokens"
+)
+
+const pragmaPrefix = "idioma:"
+
+// location of the "// idioma: <name>" pragma on the input
+type pragma struct {
+	name       string
+	start, end int // byte offsets of the name
+}
+
+// Searches for the keyword set pragma. The pragma has to be a comment placed before
+// any code, like:
+//
+//	// idioma: en
+func findPragma(input string) (pragma, bool) {
+	offset := 0
+
+	for _, line := range strings.SplitAfter(input, "\n") {
+		lineStart := offset
+		offset += len(line)
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		// code found before the pragma
+		if !strings.HasPrefix(trimmed, "//") {
+			break
+		}
+
+		comment := strings.TrimSpace(strings.TrimPrefix(trimmed, "//"))
+		if !strings.HasPrefix(comment, pragmaPrefix) {
+			continue
+		}
+
+		name := strings.TrimSpace(strings.TrimPrefix(comment, pragmaPrefix))
+		start := strings.Index(line, pragmaPrefix) + len(pragmaPrefix)
+		start += strings.Index(line[start:], name) + lineStart
+
+		return pragma{name: name, start: start, end: start + len(name)}, true
+	}
+
+	return pragma{}, false
+}
+
+// Translate rewrites the keywords of a program into the keyword set "to". The source
+// keywords are the ones selected by the pragma of the input, or "from" if the input
+// has no pragma. Identifiers, literals, comments and spacing are copied untouched.
+// The pragma is updated to the new set, or added when the input has none, so the
+// translated program runs with its keywords.
+//
+// Returns an error if some identifier of the program is a keyword on the target set,
+// because the translated program would not be valid.
+func Translate(input string, from, to *tokens.KeywordSet) (string, error) {
+	l := NewLexerWithKeywords(input, from)
+
+	var out strings.Builder
+	conflicts := map[string]bool{}
+	copied := 0
+
+	for token := l.NexToken(); token.Type != tokens.EOF; token = l.NexToken() {
+		if token.Type == tokens.IDENT && to.Resolve(token.Literal) != tokens.IDENT {
+			conflicts[token.Literal] = true
+			continue
+		}
+
+		// strings could contain keywords, so only keyword tokens are translated
+		if token.Type == tokens.IDENT || l.keywords.Resolve(token.Literal) != token.Type {
+			continue
+		}
+
+		word, ok := l.keywords.Translate(token.Literal, to)
+		if !ok {
+			continue
+		}
+
+		out.WriteString(input[copied:l.tokenStart])
+		out.WriteString(word)
+		copied = l.tokenStart + len(token.Literal)
+	}
+
+	out.WriteString(input[copied:])
+
+	if len(conflicts) > 0 {
+		names := make([]string, 0, len(conflicts))
+		for name := range conflicts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return "", fmt.Errorf(
+			"Cannot translate to '%s'. Identifiers used as keywords: %s",
+			to.Name, strings.Join(names, ", "))
+	}
+
+	translated := out.String()
+	if p, ok := findPragma(translated); ok {
+		translated = translated[:p.start] + to.Name + translated[p.end:]
+	} else {
+		translated = fmt.Sprintf("// %s %s\n", pragmaPrefix, to.Name) + translated
+	}
+
+	return translated, nil
+}
@@ -1,18 +1,60 @@
 package lexer
 
-import "github.com/sl2.0/tokens"
+import (
+	"fmt"
+	"strings"
 
+	"github.com/sl2.0/tokens"
+)
+
+// Lexer reads the input as UTF-8 text, one rune at a time
 type Lexer struct {
 	input string
 	// both variables are initialized on 0 by default (go's default behavior).
-	currentPosition int // position of the current character
-	nextPosition    int // position of the next character
-	ch              byte
+	currentPosition int // byte offset of the current character
+	nextPosition    int // byte offset of the next character
+	ch              rune
+	tokenStart      int // byte offset where the last returned token starts
+	tokenLine       int // line where the last returned token starts
+	tokenColumn     int // column where the last returned token starts
+	line            int // line of the current character (starting from 1)
+	column          int // column of the current character, counted in runes (starting from 1)
+
+	errors         []string
+	errorPositions []int // line of every error
+
+	keywords *tokens.KeywordSet
+
+	// brace depth of every open string interpolation, innermost last
+	interpolations []int
+
+	// return comments as COMMENT tokens instead of skipping them
+	keepComments bool
 }
 
+// Creates a lexer for the default (spanish) keywords, unless the input selects other
+// keyword set using the pragma comment "// idioma: <name>"
 func NewLexer(input string) *Lexer {
+	return NewLexerWithKeywords(input, tokens.Spanish)
+}
+
+// Creates a lexer which resolves keywords using the given set. A pragma comment on the
+// input takes precedence over the given set.
+func NewLexerWithKeywords(input string, keywords *tokens.KeywordSet) *Lexer {
 	l := &Lexer{
-		input: input,
+		input:    input,
+		keywords: keywords,
+		line:     1,
+	}
+
+	if pragma, ok := findPragma(input); ok {
+		if set, ok := tokens.LookupKeywordSet(pragma.name); ok {
+			l.keywords = set
+		} else {
+			line := strings.Count(input[:pragma.start], "\n") + 1
+			l.errors = append(l.errors, fmt.Sprintf("Unknown keyword set on pragma: '%s'", pragma.name))
+			l.errorPositions = append(l.errorPositions, line)
+		}
 	}
 
 	// initialize the lexer in a full working state
@@ -21,14 +63,23 @@ func NewLexer(input string) *Lexer {
 	return l
 }
 
+// Returns the next token of the input, including its position
 func (l *Lexer) NexToken() tokens.Token {
+	token := l.readToken()
+	token.Line = l.tokenLine
+	token.Column = l.tokenColumn
+
+	return token
+}
+
+func (l *Lexer) readToken() tokens.Token {
 	var token tokens.Token
 
 	l.burnWhiteSpaces()
 
 	// first search for comments and ignore them, consuming every
 	// character till the end of the line (or end of the file)
-	if l.ch == '/' && l.pickChar() == '/' {
+	for l.ch == '/' && l.pickChar() == '/' && !l.keepComments {
 		for l.ch != '\n' && l.ch != 0 {
 			l.readChar()
 		}
@@ -36,6 +87,15 @@ func (l *Lexer) NexToken() tokens.Token {
 		l.burnWhiteSpaces()
 	}
 
+	l.tokenStart = l.currentPosition
+	l.tokenLine = l.line
+	l.tokenColumn = l.column
+
+	// only reached when comments are kept. The line break is left for the next token.
+	if l.ch == '/' && l.pickChar() == '/' {
+		return newMultiToken(tokens.COMMENT, l.extractComment())
+	}
+
 	// start generating tokens
 	switch l.ch {
 	// operators
@@ -75,23 +135,31 @@ func (l *Lexer) NexToken() tokens.Token {
 		token = newSingleToken(tokens.SEMICOLON, l.ch)
 	case ':':
 		token = newSingleToken(tokens.COLON, l.ch)
+	case '.':
+		token = newSingleToken(tokens.DOT, l.ch)
 	case '{':
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1]++
+		}
 		token = newSingleToken(tokens.LBRAC, l.ch)
 	case '}':
+		n := len(l.interpolations)
+		if n > 0 && l.interpolations[n-1] == 0 {
+			// closes a "${", so the string literal continues
+			l.interpolations = l.interpolations[:n-1]
+			token = l.readStringPart(false)
+			break
+		}
+		if n > 0 {
+			l.interpolations[n-1]--
+		}
 		token = newSingleToken(tokens.RBRAC, l.ch)
 	case ')':
 		token = newSingleToken(tokens.RPAR, l.ch)
 	case '(':
 		token = newSingleToken(tokens.LPAR, l.ch)
 	case '"':
-		str := ""
-		for l.pickChar() != '"' {
-			l.readChar()
-			str += string(l.ch)
-		}
-		// skeep the final '"'
-		l.readChar()
-		token = newMultiToken(tokens.STRING, str)
+		token = l.readStringPart(true)
 	case '\n':
 		l.skipLineBreaks()
 		// early return to avoid errors with some multiline characters
@@ -104,13 +172,15 @@ func (l *Lexer) NexToken() tokens.Token {
 		if isLetter(l.ch) {
 			ident := l.extractIdentifier()
 			// early return to prevent reading (and skipping) the next char
-			return newMultiToken(tokens.ResolveType(ident), ident)
+			return newMultiToken(l.keywords.Resolve(ident), ident)
 		}
 
 		if isNumber(l.ch) {
-			return newMultiToken(tokens.NUMBER, l.extractNumber())
+			// early return for the same reason as identifiers
+			return l.extractNumber()
 		}
 
+		l.addError("Illegal character '%c'", l.ch)
 		token = newSingleToken(tokens.ILLEGAL, l.ch)
 	}
 
@@ -118,3 +188,25 @@ func (l *Lexer) NexToken() tokens.Token {
 
 	return token
 }
+
+// Makes the lexer return comments as COMMENT tokens instead of skipping them. Used by
+// tools which rewrite the source, like the formatter.
+func (l *Lexer) KeepComments() {
+	l.keepComments = true
+}
+
+// Returns the keyword set used by the lexer
+func (l *Lexer) Keywords() *tokens.KeywordSet {
+	return l.keywords
+}
+
+// Returns the lexical errors found so far, like malformed numbers or illegal
+// characters. Every error has a matching ILLEGAL token.
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
+// Returns the line where every error of Errors() was found, in the same order
+func (l *Lexer) ErrorPositions() []int {
+	return l.errorPositions
+}
@@ -1,6 +1,7 @@
 package lexer
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/sl2.0/tokens"
@@ -151,6 +152,66 @@ func TestNextToken(t *testing.T) {
 				{Type: tokens.STRING, Literal: "chau"},
 			},
 		},
+		{ // interpolated strings
+			`"Hola ${nombre}, tienes ${edad + 1} años"; "${ si(true){1} }"; "sin cerrar`,
+			[]tokens.Token{
+				{Type: tokens.INTERP_START, Literal: "Hola "},
+				{Type: tokens.IDENT, Literal: "nombre"},
+				{Type: tokens.INTERP_MID, Literal: ", tienes "},
+				{Type: tokens.IDENT, Literal: "edad"},
+				{Type: tokens.PLUS, Literal: "+"},
+				{Type: tokens.NUMBER, Literal: "1"},
+				{Type: tokens.INTERP_END, Literal: " años"},
+				{Type: tokens.SEMICOLON, Literal: ";"},
+				{Type: tokens.INTERP_START, Literal: ""},
+				{Type: tokens.IF, Literal: "si"},
+				{Type: tokens.LPAR, Literal: "("},
+				{Type: tokens.TRUE, Literal: "true"},
+				{Type: tokens.RPAR, Literal: ")"},
+				{Type: tokens.LBRAC, Literal: "{"},
+				{Type: tokens.NUMBER, Literal: "1"},
+				{Type: tokens.RBRAC, Literal: "}"},
+				{Type: tokens.INTERP_END, Literal: ""},
+				{Type: tokens.SEMICOLON, Literal: ";"},
+				{Type: tokens.ILLEGAL, Literal: "\"sin cerrar"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+		},
+		{ // unicode identifiers and strings
+			`var año = 2024; var número_ñandú = "¿qué tal? ☕"; añoß`,
+			[]tokens.Token{
+				{Type: tokens.VAR, Literal: "var"},
+				{Type: tokens.IDENT, Literal: "año"},
+				{Type: tokens.ASIGN, Literal: "="},
+				{Type: tokens.NUMBER, Literal: "2024"},
+				{Type: tokens.SEMICOLON, Literal: ";"},
+				{Type: tokens.VAR, Literal: "var"},
+				{Type: tokens.IDENT, Literal: "número_ñandú"},
+				{Type: tokens.ASIGN, Literal: "="},
+				{Type: tokens.STRING, Literal: "¿qué tal? ☕"},
+				{Type: tokens.SEMICOLON, Literal: ";"},
+				{Type: tokens.IDENT, Literal: "añoß"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+		},
+		{ // digits after the first letter
+			`_1 _ a2b x_10`,
+			[]tokens.Token{
+				{Type: tokens.IDENT, Literal: "_1"},
+				{Type: tokens.IDENT, Literal: "_"},
+				{Type: tokens.IDENT, Literal: "a2b"},
+				{Type: tokens.IDENT, Literal: "x_10"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+		},
+		{ // non letters are still invalid, and are never split
+			`€ ☕`,
+			[]tokens.Token{
+				{Type: tokens.ILLEGAL, Literal: "€"},
+				{Type: tokens.ILLEGAL, Literal: "☕"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+		},
 		{ // invalid tokens
 			`~@#$^&`,
 			[]tokens.Token{
@@ -207,3 +268,288 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestKeywordSets(t *testing.T) {
+	testCases := []struct {
+		input    string
+		keywords *tokens.KeywordSet
+		expected []tokens.Token
+	}{
+		{
+			input:    `if (x) { return "si"; } else { si }`,
+			keywords: tokens.English,
+			expected: []tokens.Token{
+				{Type: tokens.IF, Literal: "if"},
+				{Type: tokens.LPAR, Literal: "("},
+				{Type: tokens.IDENT, Literal: "x"},
+				{Type: tokens.RPAR, Literal: ")"},
+				{Type: tokens.LBRAC, Literal: "{"},
+				{Type: tokens.RETURN, Literal: "return"},
+				{Type: tokens.STRING, Literal: "si"},
+				{Type: tokens.SEMICOLON, Literal: ";"},
+				{Type: tokens.RBRAC, Literal: "}"},
+				{Type: tokens.ELSE, Literal: "else"},
+				{Type: tokens.LBRAC, Literal: "{"},
+				{Type: tokens.IDENT, Literal: "si"},
+				{Type: tokens.RBRAC, Literal: "}"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+		},
+		{
+			input:    `try { throw e.message } catch (e) {} finally {}`,
+			keywords: tokens.English,
+			expected: []tokens.Token{
+				{Type: tokens.TRY, Literal: "try"},
+				{Type: tokens.LBRAC, Literal: "{"},
+				{Type: tokens.THROW, Literal: "throw"},
+				{Type: tokens.IDENT, Literal: "e"},
+				{Type: tokens.DOT, Literal: "."},
+				{Type: tokens.IDENT, Literal: "message"},
+				{Type: tokens.RBRAC, Literal: "}"},
+				{Type: tokens.CATCH, Literal: "catch"},
+				{Type: tokens.LPAR, Literal: "("},
+				{Type: tokens.IDENT, Literal: "e"},
+				{Type: tokens.RPAR, Literal: ")"},
+				{Type: tokens.LBRAC, Literal: "{"},
+				{Type: tokens.RBRAC, Literal: "}"},
+				{Type: tokens.FINALLY, Literal: "finally"},
+				{Type: tokens.LBRAC, Literal: "{"},
+				{Type: tokens.RBRAC, Literal: "}"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+		},
+		{
+			input:    `import "util.sl" as util`,
+			keywords: tokens.English,
+			expected: []tokens.Token{
+				{Type: tokens.IMPORT, Literal: "import"},
+				{Type: tokens.STRING, Literal: "util.sl"},
+				{Type: tokens.AS, Literal: "as"},
+				{Type: tokens.IDENT, Literal: "util"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+		},
+		{ // the pragma takes precedence over the given set
+			"// programa de prueba\n// idioma: en\nrepeat 2 { retorna }",
+			tokens.Spanish,
+			[]tokens.Token{
+				{Type: tokens.FOR, Literal: "repeat"},
+				{Type: tokens.NUMBER, Literal: "2"},
+				{Type: tokens.LBRAC, Literal: "{"},
+				{Type: tokens.IDENT, Literal: "retorna"},
+				{Type: tokens.RBRAC, Literal: "}"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+		},
+		{ // pragmas after the code are just comments
+			"var a = 1;\n// idioma: en\nsi",
+			tokens.Spanish,
+			[]tokens.Token{
+				{Type: tokens.VAR, Literal: "var"},
+				{Type: tokens.IDENT, Literal: "a"},
+				{Type: tokens.ASIGN, Literal: "="},
+				{Type: tokens.NUMBER, Literal: "1"},
+				{Type: tokens.SEMICOLON, Literal: ";"},
+				{Type: tokens.LINEBREAK, Literal: ""},
+				{Type: tokens.IF, Literal: "si"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+		},
+	}
+
+	for id, test := range testCases {
+		lexer := NewLexerWithKeywords(test.input, test.keywords)
+
+		for i, expected := range test.expected {
+			token := lexer.NexToken()
+			if token.Type != expected.Type || token.Literal != expected.Literal {
+				t.Errorf("Test case %d, token %d: expected %s '%s'. Got %s '%s'",
+					id+1, i, expected.Type, expected.Literal, token.Type, token.Literal)
+			}
+		}
+	}
+}
+
+func TestKeepComments(t *testing.T) {
+	input := "// inicio\n// otro  \nvar a = 1; // fin"
+	expected := []tokens.Token{
+		{Type: tokens.COMMENT, Literal: "// inicio", Line: 1, Column: 1},
+		{Type: tokens.LINEBREAK, Literal: "", Line: 1, Column: 10},
+		{Type: tokens.COMMENT, Literal: "// otro", Line: 2, Column: 1},
+		{Type: tokens.LINEBREAK, Literal: "", Line: 2, Column: 10},
+		{Type: tokens.VAR, Literal: "var", Line: 3, Column: 1},
+		{Type: tokens.IDENT, Literal: "a", Line: 3, Column: 5},
+		{Type: tokens.ASIGN, Literal: "=", Line: 3, Column: 7},
+		{Type: tokens.NUMBER, Literal: "1", Line: 3, Column: 9},
+		{Type: tokens.SEMICOLON, Literal: ";", Line: 3, Column: 10},
+		{Type: tokens.COMMENT, Literal: "// fin", Line: 3, Column: 12},
+		{Type: tokens.EOF, Literal: "", Line: 3, Column: 18},
+	}
+
+	lexer := NewLexer(input)
+	lexer.KeepComments()
+
+	for i, expected := range expected {
+		token := lexer.NexToken()
+		if token != expected {
+			t.Errorf("token %d: expected %+v. Got %+v", i, expected, token)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	input := `// idioma: es
+// si el numero es grande
+func mayor(n: entero) {
+    si (n > 10) {
+        retorna "si, es mayor";
+    } sino {
+        repetir 2 { var n = n + 1; }
+    }
+    retorna n;
+}`
+
+	expected := `// idioma: en
+// si el numero es grande
+func mayor(n: int) {
+    if (n > 10) {
+        return "si, es mayor";
+    } else {
+        repeat 2 { var n = n + 1; }
+    }
+    return n;
+}`
+
+	translated, err := Translate(input, tokens.Spanish, tokens.English)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if translated != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, translated)
+	}
+
+	// and back again
+	back, err := Translate(translated, tokens.English, tokens.Spanish)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if back != input {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", input, back)
+	}
+
+	// without a pragma, one is added with the new set
+	translated, err = Translate("si (true) { retorna 1 }\n", tokens.Spanish, tokens.English)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected = "// idioma: en\nif (true) { return 1 }\n"
+	if translated != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, translated)
+	}
+
+	if l := NewLexer(translated); l.Keywords() != tokens.English {
+		t.Fatalf("Expected the translated program to be read with the English keywords")
+	}
+
+	// identifiers which are keywords on the target set
+	_, err = Translate("var if = 2; var string = if;", tokens.Spanish, tokens.English)
+	if err == nil || !strings.Contains(err.Error(), "if, string") {
+		t.Fatalf("Expected an error listing the conflicting identifiers. Got %v", err)
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []tokens.Token
+		errors   []string
+	}{
+		{
+			input: `0xFF 0Xff 0b1010 0o755 1_000_000 0x_FF_FF 0 9223372036854775807`,
+			expected: []tokens.Token{
+				{Type: tokens.NUMBER, Literal: "0xFF"},
+				{Type: tokens.NUMBER, Literal: "0Xff"},
+				{Type: tokens.NUMBER, Literal: "0b1010"},
+				{Type: tokens.NUMBER, Literal: "0o755"},
+				{Type: tokens.NUMBER, Literal: "1_000_000"},
+				{Type: tokens.NUMBER, Literal: "0x_FF_FF"},
+				{Type: tokens.NUMBER, Literal: "0"},
+				{Type: tokens.NUMBER, Literal: "9223372036854775807"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+		},
+		{
+			input: "0x; 1__0 10_\n  12abc 0b102 0o8 0xfg\n010 09 99999999999999999999 0x1_0000_0000_0000_0000",
+			expected: []tokens.Token{
+				{Type: tokens.ILLEGAL, Literal: "0x"},
+				{Type: tokens.SEMICOLON, Literal: ";"},
+				{Type: tokens.ILLEGAL, Literal: "1__0"},
+				{Type: tokens.ILLEGAL, Literal: "10_"},
+				{Type: tokens.LINEBREAK, Literal: ""},
+				{Type: tokens.ILLEGAL, Literal: "12abc"},
+				{Type: tokens.ILLEGAL, Literal: "0b102"},
+				{Type: tokens.ILLEGAL, Literal: "0o8"},
+				{Type: tokens.ILLEGAL, Literal: "0xfg"},
+				{Type: tokens.LINEBREAK, Literal: ""},
+				{Type: tokens.ILLEGAL, Literal: "010"},
+				{Type: tokens.ILLEGAL, Literal: "09"},
+				{Type: tokens.ILLEGAL, Literal: "99999999999999999999"},
+				{Type: tokens.ILLEGAL, Literal: "0x1_0000_0000_0000_0000"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+			errors: []string{
+				"Malformed number '0x': missing digits after the prefix (line 1, column 1)",
+				"Malformed number '1__0': '_' must separate digits (line 1, column 5)",
+				"Malformed number '10_': '_' must separate digits (line 1, column 10)",
+				"Malformed number '12abc': identifiers cannot start with a digit (line 2, column 3)",
+				"Malformed number '0b102': invalid digit '2' in binary literal (line 2, column 9)",
+				"Malformed number '0o8': invalid digit '8' in octal literal (line 2, column 15)",
+				"Malformed number '0xfg': invalid digit 'g' in hexadecimal literal (line 2, column 19)",
+				"Malformed number '010': decimal literals cannot start with '0' (use '0o' for octal) (line 3, column 1)",
+				"Malformed number '09': decimal literals cannot start with '0' (use '0o' for octal) (line 3, column 5)",
+				"Malformed number '99999999999999999999': out of the range of 64-bit integers (line 3, column 8)",
+				"Malformed number '0x1_0000_0000_0000_0000': out of the range of 64-bit integers (line 3, column 29)",
+			},
+		},
+		{
+			input: "\"ñandú\" @ \"sin cerrar",
+			expected: []tokens.Token{
+				{Type: tokens.STRING, Literal: "ñandú"},
+				{Type: tokens.ILLEGAL, Literal: "@"},
+				{Type: tokens.ILLEGAL, Literal: "\"sin cerrar"},
+				{Type: tokens.EOF, Literal: ""},
+			},
+			errors: []string{
+				"Illegal character '@' (line 1, column 9)",
+				"Unterminated string (line 1, column 11)",
+			},
+		},
+	}
+
+	for id, test := range testCases {
+		lexer := NewLexer(test.input)
+
+		for i, expected := range test.expected {
+			token := lexer.NexToken()
+			if token.Type != expected.Type || token.Literal != expected.Literal {
+				t.Errorf("Test case %d, token %d: expected %s '%s'. Got %s '%s'",
+					id+1, i, expected.Type, expected.Literal, token.Type, token.Literal)
+			}
+		}
+
+		errors := lexer.Errors()
+		if len(errors) != len(test.errors) {
+			t.Errorf("Test case %d: expected %d errors. Got %d: %v", id+1, len(test.errors), len(errors), errors)
+			continue
+		}
+
+		for i, expected := range test.errors {
+			if errors[i] != expected {
+				t.Errorf("Test case %d:\nExpected error: %s\n\tGot: %s", id+1, expected, errors[i])
+			}
+		}
+	}
+}
@@ -1,9 +1,17 @@
 package lexer
 
-import "github.com/sl2.0/tokens"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sl2.0/tokens"
+)
 
 // generates a new "single char token" from the given token type and char
-func newSingleToken(ty tokens.TokenType, ch byte) tokens.Token {
+func newSingleToken(ty tokens.TokenType, ch rune) tokens.Token {
 	return tokens.Token{
 		Type:    ty,
 		Literal: string(ch),
@@ -18,53 +26,138 @@ func newMultiToken(ty tokens.TokenType, lit string) tokens.Token {
 	}
 }
 
-// reads a new character and advances the lexer state
+// reads a new character (a complete UTF-8 rune) and advances the lexer state
 func (l *Lexer) readChar() {
+	width := 1
+
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
+
 	if l.nextPosition >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.nextPosition]
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.nextPosition:])
 	}
 
 	l.currentPosition = l.nextPosition
-	l.nextPosition++
+	l.nextPosition += width
 }
 
 // reads a new character WITHOUT changing the lexer state an returns the caracter
-func (l Lexer) pickChar() byte {
+func (l Lexer) pickChar() rune {
 	if l.nextPosition >= len(l.input) {
 		return 0
 	} else {
-		return l.input[l.nextPosition]
+		ch, _ := utf8.DecodeRuneInString(l.input[l.nextPosition:])
+		return ch
 	}
 }
 
-func isLetter(ch byte) bool {
-	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch == '_'
+// any unicode letter is valid inside identifiers, so "año" or "número" can be used
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
 }
 
+// identifiers start with a letter, and can contain digits after it (like "_1")
 func (l *Lexer) extractIdentifier() string {
 	auxPos := l.currentPosition
 
-	for isLetter(l.ch) {
+	for isLetter(l.ch) || isNumber(l.ch) {
 		l.readChar()
 	}
 
 	return l.input[auxPos:l.currentPosition]
 }
 
-func isNumber(ch byte) bool {
+// extracts a comment till the end of the line, without the trailing spaces
+func (l *Lexer) extractComment() string {
+	auxPos := l.currentPosition
+
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+
+	return strings.TrimRightFunc(l.input[auxPos:l.currentPosition], unicode.IsSpace)
+}
+
+func isNumber(ch rune) bool {
 	return ch >= '0' && ch <= '9'
 }
 
-func (l *Lexer) extractNumber() string {
+// extracts a number literal, which can be decimal (1_000), hexadecimal (0xFF), binary
+// (0b1010) or octal (0o755). Underscores are allowed between digits.
+// Malformed literals generate an ILLEGAL token.
+func (l *Lexer) extractNumber() tokens.Token {
+	line, column := l.line, l.column
 	auxPos := l.currentPosition
 
-	for isNumber(l.ch) {
+	// consume letters too, so "12abc" or "0xZZ" are reported as a whole
+	for isNumber(l.ch) || isLetter(l.ch) {
 		l.readChar()
 	}
 
-	return l.input[auxPos:l.currentPosition]
+	literal := l.input[auxPos:l.currentPosition]
+
+	if msg := validateNumber(literal); msg != "" {
+		l.addErrorAt(line, column, "Malformed number '%s': %s", literal, msg)
+		return newMultiToken(tokens.ILLEGAL, literal)
+	}
+
+	return newMultiToken(tokens.NUMBER, literal)
+}
+
+var numberBases = map[byte]struct {
+	name   string
+	digits string
+}{
+	'x': {"hexadecimal", "0123456789abcdefABCDEF"},
+	'b': {"binary", "01"},
+	'o': {"octal", "01234567"},
+}
+
+// Returns a description of the problem if the literal is not a valid number
+func validateNumber(literal string) string {
+	digits := literal
+	name, valid := "decimal", "0123456789"
+
+	if len(literal) > 1 && literal[0] == '0' {
+		base, ok := numberBases[literal[1]|0x20] // lower case the prefix
+		if !ok {
+			// other languages read them as octal, so they are rejected to avoid confusion
+			return "decimal literals cannot start with '0' (use '0o' for octal)"
+		}
+
+		name, valid = base.name, base.digits
+		digits = literal[2:]
+
+		if strings.Trim(digits, "_") == "" {
+			return "missing digits after the prefix"
+		}
+	}
+
+	for i, ch := range digits {
+		switch {
+		case ch == '_':
+			if i+1 == len(digits) || digits[i+1] == '_' {
+				return "'_' must separate digits"
+			}
+		case !strings.ContainsRune(valid, ch):
+			if name == "decimal" {
+				return "identifiers cannot start with a digit"
+			}
+			return fmt.Sprintf("invalid digit '%c' in %s literal", ch, name)
+		}
+	}
+
+	// the prefix and the separators are the ones of go
+	if _, err := strconv.ParseInt(literal, 0, 64); err != nil {
+		return "out of the range of 64-bit integers"
+	}
+
+	return ""
 }
 
 func (l *Lexer) burnWhiteSpaces() {
@@ -78,3 +171,50 @@ func (l *Lexer) skipLineBreaks() {
 		l.readChar()
 	}
 }
+
+// reads the content of a string literal until the closing '"' or the start of an
+// interpolation ("${"), leaving the lexer over the last consumed character.
+// "head" tells if the reading starts at the opening '"' or after the "}" that closes
+// an interpolation.
+func (l *Lexer) readStringPart(head bool) tokens.Token {
+	line, column := l.line, l.column
+	var str strings.Builder
+
+	for {
+		l.readChar()
+
+		switch {
+		case l.ch == 0:
+			l.addErrorAt(line, column, "Unterminated string")
+			return newMultiToken(tokens.ILLEGAL, "\""+str.String())
+
+		case l.ch == '"':
+			if head {
+				return newMultiToken(tokens.STRING, str.String())
+			}
+			return newMultiToken(tokens.INTERP_END, str.String())
+
+		case l.ch == '$' && l.pickChar() == '{':
+			l.readChar()
+			l.interpolations = append(l.interpolations, 0)
+
+			if head {
+				return newMultiToken(tokens.INTERP_START, str.String())
+			}
+			return newMultiToken(tokens.INTERP_MID, str.String())
+		}
+
+		str.WriteRune(l.ch)
+	}
+}
+
+// registers an error at the position of the current character
+func (l *Lexer) addError(format string, args ...interface{}) {
+	l.addErrorAt(l.line, l.column, format, args...)
+}
+
+func (l *Lexer) addErrorAt(line, column int, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	l.errors = append(l.errors, fmt.Sprintf("%s (line %d, column %d)", msg, line, column))
+	l.errorPositions = append(l.errorPositions, line)
+}
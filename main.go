@@ -1,86 +1,69 @@
 package main
 
 import (
-	"flag"
 	"fmt"
-	"log"
 	"os"
-
-	"github.com/sl2.0/repl"
 )
 
-func main() {
-	const colorMagenta = "\033[35m"
-	const colorNone = "\033[0m"
-
-	// Define flags
-	mode := flag.String("mode", "eval", "Available modes: lexer, parser, eval(default)")
-	quiet := flag.Bool("quiet", false, "Suppres unnecesary messages")
-	maxTime := flag.Int64("max-time", 40000, "Max time for execution")
-
-	inputFile := flag.String("file", "", "Execute the given file")
-	outputFile := flag.String("o", "", "File to output the result")
-	errFile := flag.String("err", "", "File to output the errors")
-
-	// Parse command-line flags
-	flag.Parse()
-
-	builder := repl.NewReplBuilder()
-
-	// Check if data is being piped into Stdin
-	stat, _ := os.Stdin.Stat()
-	if (stat.Mode() & os.ModeCharDevice) == 0 {
-		builder = builder.WithStdin(os.Stdin)
-	} else if *inputFile != "" { // If a file is provided
-		f, err := os.Open(*inputFile)
-		if err != nil {
-			log.Fatal("Error opening input file: " + err.Error())
-		}
-		defer f.Close()
+type subcommand struct {
+	name  string
+	usage string
+	help  string
+	run   func(args []string) int
+}
 
-		builder = builder.WithStdin(f)
-	} else { // Run REPL on interactive mode
-		builder = builder.Interactive()
+// initialized on init() because "help" needs to list every subcommand
+var subcommands []subcommand
+
+func init() {
+	subcommands = []subcommand{
+		{name: "run", usage: "[flags] [file|-] [-- args...]", help: "Execute a program", run: run},
+		{name: "repl", usage: "[flags]", help: "Start the interactive mode (default)", run: interactive},
+		{name: "debug", usage: "[flags] file [-- args...]", help: "Run a program under the debugger", run: debug},
+		{name: "dap", usage: "", help: "Serve the Debug Adapter Protocol over stdio", run: serveDAP},
+		{name: "tokens", usage: "[flags] [file|-]", help: "Print the tokens of a program", run: lexe},
+		{name: "ast", usage: "[flags] [file|-]", help: "Print the AST of a program", run: parse},
+		{name: "test", usage: "[flags] [path]...", help: "Run the tests of the *_prueba.sl files", run: runTests},
+		{name: "verify", usage: "[flags] [path]...", help: "Compare the results of programs with their .out/.err files", run: verify},
+		{name: "check", usage: "[flags] [file|-]...", help: "Report the syntax errors of programs", run: check},
+		{name: "fmt", usage: "[flags] [file|-]...", help: "Format programs", run: formatFiles},
+		{name: "translate", usage: "[flags] [file]", help: "Translate a program to other keyword set", run: translate},
+		{name: "replay", usage: "[flags] file", help: "Reproduce a transcript of the interactive mode", run: replay},
+		{name: "help", usage: "", help: "Show this help", run: help},
 	}
+}
 
-	if *outputFile != "" {
-		f, err := os.Open(*outputFile)
-		if err != nil {
-			log.Fatal("Error opening output file: " + err.Error())
-		}
-		defer f.Close()
-		builder = builder.WithStdout(f)
+func main() {
+	// without a subcommand the interactive mode is started
+	if len(os.Args) < 2 {
+		os.Exit(interactive(nil))
 	}
 
-	if *errFile != "" {
-		f, err := os.Open(*errFile)
-		if err != nil {
-			log.Fatal("Error opening error file: " + err.Error())
-		}
-		defer f.Close()
-		builder = builder.WithStderr(f)
+	name := os.Args[1]
+	if name == "-h" || name == "--help" {
+		name = "help"
 	}
 
-	switch *mode {
-	case "lexer":
-		builder = builder.WithMode(repl.LEXER)
-	case "parser":
-		builder = builder.WithMode(repl.PARSER)
-	case "eval":
-		builder = builder.WithMode(repl.EVAL)
-	default:
-		log.Fatal("Invalid mode")
+	for _, cmd := range subcommands {
+		if cmd.name == name {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
 	}
 
-	// Set max time execution for evaluation
-	builder = builder.WithTimeout(*maxTime)
+	fmt.Fprintf(os.Stderr, "Unknown subcommand '%s'\n", name)
+	usage()
+	os.Exit(exitUsage)
+}
 
-	replInstance := builder.Build()
+func help([]string) int {
+	usage()
+	return 0
+}
 
-	// On quiet mode this lines are not printed
-	if !*quiet {
-		fmt.Printf("Starting REPL in %s%s%s mode...\n", colorMagenta, *mode, colorNone)
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <subcommand> [flags] [args]\n\nSubcommands:\n", os.Args[0])
+	for _, cmd := range subcommands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.help)
 	}
-
-	replInstance.Run()
+	fmt.Fprintf(os.Stderr, "\nRun '%s <subcommand> -h' for the flags of a subcommand\n", os.Args[0])
 }
@@ -2,6 +2,8 @@ package objects
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/sl2.0/ast"
 )
@@ -14,13 +16,19 @@ type Object interface {
 }
 
 const (
-	INTEGER_OBJ = "INTEGER"
-	STRING_OBJ  = "STRING"
-	BOOL_OBJ    = "BOOL"
-	NULL_OBJ    = "NULL"
-	ERROR_OBJ   = "ERROR"
-	RETURN_OBJ  = "RETURN"
-	FUNC_OBJ    = "FUNCTION"
+	INTEGER_OBJ   = "INTEGER"
+	STRING_OBJ    = "STRING"
+	BOOL_OBJ      = "BOOL"
+	NULL_OBJ      = "NULL"
+	ERROR_OBJ     = "ERROR"
+	RETURN_OBJ    = "RETURN"
+	FUNC_OBJ      = "FUNCTION"
+	BUILTIN_OBJ   = "BUILTIN"
+	ARRAY_OBJ     = "ARRAY"
+	MAP_OBJ       = "MAP"
+	EXIT_OBJ      = "EXIT"
+	EXCEPTION_OBJ = "EXCEPTION"
+	MODULE_OBJ    = "MODULE"
 )
 
 // --- Primitive data types ---
@@ -60,8 +68,51 @@ func (i *String) Inspect() string {
 
 // --- Complex data types ---
 
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType {
+	return ARRAY_OBJ
+}
+func (a *Array) Inspect() string {
+	elements := make([]string, 0, len(a.Elements))
+	for _, element := range a.Elements {
+		elements = append(elements, element.Inspect())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+type ErrorKind string
+
+const (
+	RUNTIME_ERROR ErrorKind = "RuntimeError"
+	LIMIT_ERROR   ErrorKind = "LimitError"     // an execution limit was exceeded
+	CANCEL_ERROR  ErrorKind = "CancelError"    // the context of the evaluation is done
+	USER_ERROR    ErrorKind = "UserError"      // thrown with "lanzar"
+	ASSERT_ERROR  ErrorKind = "AssertionError" // a failed "afirmar"
+)
+
 type ErrorObject struct {
 	error string
+	Kind  ErrorKind
+
+	// calls unwound by the error, from the innermost one
+	Trace []TraceFrame
+
+	Value Object // thrown value of user errors
+}
+
+// A call on the stack trace of an error
+type TraceFrame struct {
+	Function string
+	Args     string // summary of the arguments
+	File     string // file of the function if it belongs to a module ("" for the program)
+
+	// position of the statement which failed, or of the call to the next frame
+	Line   int
+	Column int
 }
 
 func (b *ErrorObject) Type() ObjectType {
@@ -69,13 +120,60 @@ func (b *ErrorObject) Type() ObjectType {
 }
 
 func NewError(format string, message ...interface{}) Object {
-	return &ErrorObject{error: fmt.Sprintf(format, message...)}
+	return NewErrorOfKind(RUNTIME_ERROR, format, message...)
+}
+
+func NewErrorOfKind(kind ErrorKind, format string, message ...interface{}) Object {
+	return &ErrorObject{error: fmt.Sprintf(format, message...), Kind: kind}
 }
 
 func (b *ErrorObject) Inspect() string {
 	return b.error
 }
 
+// Only runtime errors and the ones thrown by the programs can be caught. Limits,
+// cancellations, failed assertions and exits always stop the evaluation.
+func (b *ErrorObject) Catchable() bool {
+	return b.Kind == RUNTIME_ERROR || b.Kind == USER_ERROR
+}
+
+// min number of repetitions of a call collapsed on the stack traces
+const collapsedCalls = 3
+
+// Returns the stack trace as indented lines like "  en f (línea 5)", from the innermost
+// call. The frames of modules also show their file, like "  en u.f (util.sl, línea 2)".
+// Many consecutive calls on the same line (like on recursions) are collapsed.
+func (b *ErrorObject) StackTrace() string {
+	lines := []string{}
+	for n := 0; n < len(b.Trace); {
+		frame := b.Trace[n]
+		line := fmt.Sprintf("  en %s (línea %d)", frame.Function, frame.Line)
+		if frame.File != "" {
+			line = fmt.Sprintf("  en %s (%s, línea %d)", frame.Function, filepath.Base(frame.File), frame.Line)
+		}
+
+		repeated := 1
+		for n++; n < len(b.Trace) && sameCall(b.Trace[n], frame); n++ {
+			repeated++
+		}
+
+		if repeated > collapsedCalls {
+			lines = append(lines, line, fmt.Sprintf("  ... repetido %d veces más", repeated-1))
+			continue
+		}
+		for ; repeated > 0; repeated-- {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Reports if the frames are calls to the same function from the same line
+func sameCall(a, b TraceFrame) bool {
+	return a.Function == b.Function && a.File == b.File && a.Line == b.Line
+}
+
 type ReturnObject struct {
 	Value Object
 }
@@ -87,14 +185,73 @@ func (r *ReturnObject) Inspect() string {
 	return fmt.Sprintf("%d", r.Value)
 }
 
+type MapPair struct {
+	Key   Object
+	Value Object
+}
+
+// Maps keep the order of their pairs
+type Map struct {
+	Pairs []MapPair
+}
+
+func (m *Map) Type() ObjectType {
+	return MAP_OBJ
+}
+func (m *Map) Inspect() string {
+	pairs := make([]string, 0, len(m.Pairs))
+	for _, pair := range m.Pairs {
+		pairs = append(pairs, pair.Key.Inspect()+": "+pair.Value.Inspect())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// Requests the end of the program with the given exit status. Like errors, it stops the
+// evaluation of everything around it.
+type ExitObject struct {
+	Code int64
+}
+
+func (e *ExitObject) Type() ObjectType {
+	return EXIT_OBJ
+}
+func (e *ExitObject) Inspect() string {
+	return fmt.Sprintf("exit %d", e.Code)
+}
+
+// Error caught by a "capturar" block
+type Exception struct {
+	Message string
+	Kind    ErrorKind
+
+	// position where the error was raised
+	Line   int
+	Column int
+
+	Value Object // thrown value (nil for runtime errors)
+}
+
+func (e *Exception) Type() ObjectType {
+	return EXCEPTION_OBJ
+}
+func (e *Exception) Inspect() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Message)
+}
+
 type FunctionObject struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
-	Env        Storage
+
+	// scope of the module which defines the function. Nil for the functions of the
+	// program, which are evaluated on the scope of their callers.
+	Env *Storage
+	// file of the module which defines the function ("" for the ones of the program)
+	File string
 }
 
 func (f *FunctionObject) Type() ObjectType {
-	return RETURN_OBJ
+	return FUNC_OBJ
 }
 func (f *FunctionObject) Inspect() string {
 	s := "("
@@ -105,3 +262,30 @@ func (f *FunctionObject) Inspect() string {
 
 	return s + "\n" + f.Body.ToString(0)
 }
+
+// Functions implemented in Go and provided by the interpreter
+type BuiltinFunction struct {
+	Name string
+	Fn   func(args ...Object) Object
+}
+
+func (b *BuiltinFunction) Type() ObjectType {
+	return BUILTIN_OBJ
+}
+func (b *BuiltinFunction) Inspect() string {
+	return "builtin " + b.Name
+}
+
+// File loaded with "importar". Its top-level bindings are accessed as members.
+type Module struct {
+	Name string
+	Path string // absolute path of the file (empty for the standard library)
+	Env  *Storage
+}
+
+func (m *Module) Type() ObjectType {
+	return MODULE_OBJ
+}
+func (m *Module) Inspect() string {
+	return "module " + m.Name
+}
@@ -1,6 +1,9 @@
 package objects
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 type Storage struct {
 	identifiers map[string]Object
@@ -29,6 +32,21 @@ func NewEnclosedStorage(outer *Storage) (*Storage, error) {
 	}, nil
 }
 
+// Creates a scope enclosed by outer, with the recursion level of the caller scope. Used
+// by the functions of modules, which see their module instead of the caller scope.
+func NewCallStorage(outer, caller *Storage) (*Storage, error) {
+	lvl := max(outer.lvl, caller.lvl) + 1
+	if lvl > 200 {
+		return nil, fmt.Errorf("Max level of recursion reached")
+	}
+
+	return &Storage{
+		identifiers: make(map[string]Object),
+		outer:       outer,
+		lvl:         lvl,
+	}, nil
+}
+
 func (e *Storage) Get(ident string) (Object, bool) {
 	value, ok := e.identifiers[ident]
 
@@ -43,3 +61,33 @@ func (e *Storage) Set(ident string, obj Object) Object {
 	e.identifiers[ident] = obj
 	return obj
 }
+
+// Returns the sorted names of the identifiers defined on this scope (without the
+// outer ones)
+func (e *Storage) Identifiers() []string {
+	names := make([]string, 0, len(e.identifiers))
+	for name := range e.identifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Returns the enclosing scope (nil for the outermost one)
+func (e *Storage) Outer() *Storage {
+	return e.outer
+}
+
+// Replaces the value of an identifier on the innermost scope which defines it. Returns
+// false if no scope defines it.
+func (e *Storage) Assign(ident string, obj Object) bool {
+	for scope := e; scope != nil; scope = scope.outer {
+		if _, ok := scope.identifiers[ident]; ok {
+			scope.identifiers[ident] = obj
+			return true
+		}
+	}
+
+	return false
+}
@@ -19,6 +19,7 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	}
 
 	p.advanceToken()
+	p.skipLineBreaks()
 
 	exp.Right = p.parseExpression(PREFIX)
 
@@ -34,22 +35,91 @@ func (p *Parser) parseNumber() ast.Expression {
 
 	if exp == nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.currentToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(msg)
 	}
 
 	return exp
 }
 
+// The lexer already reports the reason of every illegal token, so the expression is
+// just discarded
+func (p *Parser) parseIllegal() ast.Expression {
+	p.illegal = true
+	return nil
+}
+
 func (p *Parser) parseString() ast.Expression {
 	return ast.NewString(p.currentToken)
 }
 
+// Parses the sequence of tokens generated by the lexer for an interpolated string:
+// INTERP_START expression (INTERP_MID expression)* INTERP_END
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	exp := ast.NewInterpolatedString(p.currentToken)
+	appendTextPart(exp, p.currentToken)
+
+	for {
+		// step over the text part
+		p.advanceToken()
+
+		part := p.parseExpression(LOWEST)
+		if part == nil {
+			return nil
+		}
+
+		exp.Parts = append(exp.Parts, part)
+
+		// block expressions (like "si") already step over their closing "}"
+		if endsWithBlock(part) && p.curTokenIs(tokens.INTERP_MID) {
+			appendTextPart(exp, p.currentToken)
+			continue
+		}
+
+		if endsWithBlock(part) && p.curTokenIs(tokens.INTERP_END) {
+			appendTextPart(exp, p.currentToken)
+			return exp
+		}
+
+		if p.nextTokenIs(tokens.INTERP_MID) {
+			p.advanceToken()
+			appendTextPart(exp, p.currentToken)
+			continue
+		}
+
+		if !p.advanceIfNextToken(tokens.INTERP_END) {
+			p.addError("Missing closing '}' on string interpolation")
+			return nil
+		}
+
+		appendTextPart(exp, p.currentToken)
+
+		return exp
+	}
+}
+
+// expressions whose parsing finishes after the token that closes their last block
+func endsWithBlock(exp ast.Expression) bool {
+	switch exp.(type) {
+	case *ast.IfExpression, *ast.ForLoop, *ast.AnonymousFunction:
+		return true
+	}
+
+	return false
+}
+
+// adds the literal text of an interpolated string token, skipping empty ones
+func appendTextPart(exp *ast.InterpolatedString, t tokens.Token) {
+	if t.Literal != "" {
+		exp.Parts = append(exp.Parts, ast.NewString(t))
+	}
+}
+
 func (p *Parser) parseBoolExpression() ast.Expression {
 	exp := ast.NewBoolean(p.currentToken)
 
 	if exp == nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.currentToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(msg)
 	}
 
 	return exp
@@ -59,7 +129,7 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	exp := ast.NewIfExpression(p.currentToken)
 
 	if !p.advanceIfNextToken(tokens.LPAR) {
-		p.errors = append(p.errors, "Missing '(' after if expression")
+		p.addError("Missing '(' after if expression")
 		return nil
 	}
 
@@ -74,12 +144,12 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	exp.Condition = condition
 
 	if !p.advanceIfNextToken(tokens.RPAR) {
-		p.errors = append(p.errors, "Missing ')' on if expression")
+		p.addError("Missing ')' on if expression")
 		return nil
 	}
 
 	if !p.advanceIfNextToken(tokens.LBRAC) {
-		p.errors = append(p.errors, "Missing '{' on if expression")
+		p.addError("Missing '{' on if expression")
 		return nil
 	}
 
@@ -118,6 +188,7 @@ func (p *Parser) parseAnonnymousFunction() ast.Expression {
 
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	p.advanceToken()
+	p.skipLineBreaks()
 
 	exp := p.parseExpression(LOWEST)
 
@@ -142,6 +213,7 @@ func (p *Parser) parseInfixExpression(e ast.Expression) ast.Expression {
 	precedence := p.curPrecendence()
 
 	p.advanceToken()
+	p.skipLineBreaks()
 
 	exp.Right = p.parseExpression(precedence)
 
@@ -154,6 +226,22 @@ func (p *Parser) parseCall(e ast.Expression) ast.Expression {
 	return f
 }
 
+func (p *Parser) parseMember(e ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{
+		Object: e,
+		Token:  p.currentToken,
+	}
+
+	if !p.advanceIfNextToken(tokens.IDENT) {
+		p.addError("Missing member name after '.'")
+		return nil
+	}
+
+	exp.Member = ast.NewIdentifier(p.currentToken)
+
+	return exp
+}
+
 func (p *Parser) parseCallArguments() []ast.Expression {
 	args := []ast.Expression{}
 
@@ -164,6 +252,7 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 	}
 
 	p.advanceToken()
+	p.skipLineBreaks()
 
 	args = append(args, p.parseExpression(LOWEST))
 
@@ -171,6 +260,7 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 		// jump comma and place on next ident
 		p.advanceToken()
 		p.advanceToken()
+		p.skipLineBreaks()
 		args = append(args, p.parseExpression(LOWEST))
 	}
 
@@ -185,14 +275,14 @@ func (p *Parser) parseForLoop() ast.Expression {
 	exp := ast.NewForLoop(p.currentToken)
 
 	if !p.advanceIfNextToken(tokens.NUMBER) {
-		p.errors = append(p.errors, "Missing 'iterations' on for loop")
+		p.addError("Missing 'iterations' on for loop")
 		return nil
 	}
 
 	exp.Iterations = *ast.NewInteger(p.currentToken)
 
 	if !p.advanceIfNextToken(tokens.LBRAC) {
-		p.errors = append(p.errors, "Missing opening '{' on for loop body")
+		p.addError("Missing opening '{' on for loop body")
 		return nil
 	}
 
@@ -12,8 +12,12 @@ type (
 )
 
 type Parser struct {
-	lexer  *lexer.Lexer
-	errors []string
+	lexer          *lexer.Lexer
+	errors         []string
+	errorPositions []int // line of every error
+
+	errorLines []int // lines where the statements with errors start
+	illegal    bool  // the current statement has illegal tokens
 
 	currentToken tokens.Token
 	nextToken    tokens.Token
@@ -30,6 +34,7 @@ const (
 	PROD      // * /
 	PREFIX    // -X  !X
 	CALL      // foo(bar)
+	MEMBER    // foo.bar
 )
 
 var precedences = map[string]int{
@@ -43,6 +48,7 @@ var precedences = map[string]int{
 	tokens.SLASH:    PROD,
 	tokens.FUNCTION: CALL,
 	tokens.LPAR:     CALL,
+	tokens.DOT:      MEMBER,
 }
 
 // Generates a new parser using the given input string
@@ -85,12 +91,14 @@ func (parser *Parser) InitParsingFns() {
 	parser.registerPrefixFn(tokens.IDENT, parser.parseIdentifier)
 	parser.registerPrefixFn(tokens.NUMBER, parser.parseNumber)
 	parser.registerPrefixFn(tokens.STRING, parser.parseString)
+	parser.registerPrefixFn(tokens.INTERP_START, parser.parseInterpolatedString)
 	parser.registerPrefixFn(tokens.TRUE, parser.parseBoolExpression)
 	parser.registerPrefixFn(tokens.FALSE, parser.parseBoolExpression)
 	parser.registerPrefixFn(tokens.LPAR, parser.parseGroupedExpression)
 	parser.registerPrefixFn(tokens.IF, parser.parseIfExpression)
 	parser.registerPrefixFn(tokens.FUNCTION, parser.parseAnonnymousFunction)
 	parser.registerPrefixFn(tokens.FOR, parser.parseForLoop)
+	parser.registerPrefixFn(tokens.ILLEGAL, parser.parseIllegal)
 
 	parser.registerInfixFn(tokens.MINUS, parser.parseInfixExpression)
 	parser.registerInfixFn(tokens.PLUS, parser.parseInfixExpression)
@@ -101,6 +109,7 @@ func (parser *Parser) InitParsingFns() {
 	parser.registerInfixFn(tokens.EQUALS, parser.parseInfixExpression)
 	parser.registerInfixFn(tokens.NOTEQUAL, parser.parseInfixExpression)
 	parser.registerInfixFn(tokens.LPAR, parser.parseCall)
+	parser.registerInfixFn(tokens.DOT, parser.parseMember)
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
@@ -108,7 +117,13 @@ func (p *Parser) ParseProgram() *ast.Program {
 	tree.Statements = []ast.Statement{}
 
 	for !p.curTokenIs(tokens.EOF) {
+		line, errors := p.currentToken.Line, len(p.errors)
+		p.illegal = false
+
 		stmt := p.parseStatement()
+		if len(p.errors) > errors || p.illegal {
+			p.addErrorLine(line)
+		}
 
 		if stmt != nil {
 			tree.Statements = append(tree.Statements, stmt)
@@ -117,22 +132,58 @@ func (p *Parser) ParseProgram() *ast.Program {
 		p.advanceToken()
 	}
 
+	// lexical errors are reported first, as they are usually the cause of the others
+	if lexErrors := p.lexer.Errors(); len(lexErrors) != 0 {
+		p.errors = append(append([]string{}, lexErrors...), p.errors...)
+		p.errorPositions = append(append([]int{}, p.lexer.ErrorPositions()...), p.errorPositions...)
+	}
+
 	return tree
 }
 
 func (p *Parser) parseStatement() ast.Statement {
+	var stmt ast.Statement
+
+	// the parsing functions return typed pointers, so failed statements (nil pointers)
+	// are checked one by one to return an untyped nil
 	switch p.currentToken.Type {
 	case tokens.VAR:
-		return p.parseVarStatement()
+		if s := p.parseVarStatement(); s != nil {
+			stmt = s
+		}
 	case tokens.RETURN:
-		return p.parseReturnStatement()
+		if s := p.parseReturnStatement(); s != nil {
+			stmt = s
+		}
 	case tokens.FUNCTION:
-		return p.parseFunctionStatement()
-	case tokens.LINEBREAK:
+		if s := p.parseFunctionStatement(); s != nil {
+			stmt = s
+		}
+	case tokens.TRY:
+		if s := p.parseTryStatement(); s != nil {
+			stmt = s
+		}
+	case tokens.THROW:
+		if s := p.parseThrowStatement(); s != nil {
+			stmt = s
+		}
+	case tokens.TEST:
+		if s := p.parseTestStatement(); s != nil {
+			stmt = s
+		}
+	case tokens.IMPORT:
+		if s := p.parseImportStatement(); s != nil {
+			stmt = s
+		}
+	case tokens.LINEBREAK, tokens.SEMICOLON: // empty statements
 		return nil
 	default:
-		return p.parseExpressionStatement()
+		if s := p.parseExpressionStatement(); s != nil {
+			stmt = s
+		}
 	}
+
+	return stmt
 }
 
 // First parse the prefix side of the expression (identifiers, numbers and unary operators),
@@ -141,7 +192,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParseFns[p.currentToken.Type]
 
 	if prefix == nil {
-		p.errors = append(p.errors, "Not prefixFn found for: "+p.currentToken.Literal)
+		p.addError("Not prefixFn found for: " + p.currentToken.Literal)
 		return nil
 	}
 
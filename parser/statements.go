@@ -63,8 +63,12 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 
 	// step over "="
 	p.advanceToken()
+	p.skipLineBreaks()
 
 	stmt.Value = p.parseExpression(LOWEST)
+	if stmt.Value == nil {
+		return nil
+	}
 
 	p.advanceToken()
 
@@ -72,11 +76,11 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 }
 
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
-	block := &ast.BlockStatement{}
+	block := &ast.BlockStatement{Token: p.currentToken}
 	block.Statements = []ast.Statement{}
 
 	if !p.advanceIfCurToken(tokens.LBRAC) {
-		p.errors = append(p.errors, "Missing opening '{' on block statement")
+		p.addError("Missing opening '{' on block statement")
 		return nil
 	}
 
@@ -93,7 +97,7 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	}
 
 	if !p.advanceIfCurToken(tokens.RBRAC) {
-		p.errors = append(p.errors, "Missing closing '}' on block statement")
+		p.addError("Missing closing '}' on block statement")
 		return nil
 	}
 
@@ -147,3 +151,132 @@ func (p *Parser) parseFuncParameters() []*ast.Identifier {
 
 	return params
 }
+
+func (p *Parser) parseTryStatement() *ast.TryStatement {
+	stmt := &ast.TryStatement{Token: p.currentToken}
+
+	if !p.advanceIfNextToken(tokens.LBRAC) {
+		p.addError("Missing '{' on try statement")
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	if p.curTokenIs(tokens.CATCH) {
+		if !p.advanceIfNextToken(tokens.LPAR) {
+			p.addError("Missing '(' on catch block")
+			return nil
+		}
+
+		if !p.advanceIfNextToken(tokens.IDENT) {
+			p.addError("Missing the identifier of the caught error")
+			return nil
+		}
+
+		stmt.Identifier = ast.NewIdentifier(p.currentToken)
+
+		if !p.advanceIfNextToken(tokens.RPAR) || !p.advanceIfNextToken(tokens.LBRAC) {
+			return nil
+		}
+
+		stmt.Catch = p.parseBlockStatement()
+		if stmt.Catch == nil {
+			return nil
+		}
+	}
+
+	if p.curTokenIs(tokens.FINALLY) {
+		if !p.advanceIfNextToken(tokens.LBRAC) {
+			p.addError("Missing '{' on finally block")
+			return nil
+		}
+
+		stmt.Finally = p.parseBlockStatement()
+		if stmt.Finally == nil {
+			return nil
+		}
+	}
+
+	if stmt.Catch == nil && stmt.Finally == nil {
+		p.addError("Missing catch or finally block on try statement")
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{
+		Token: p.currentToken,
+	}
+
+	// step over "lanzar"
+	p.advanceToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+	if stmt.Value == nil {
+		p.addError("Missing the value of the throw statement")
+		return nil
+	}
+
+	if p.nextToken.Type == tokens.SEMICOLON {
+		p.advanceToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseTestStatement() *ast.TestStatement {
+	stmt := &ast.TestStatement{Token: p.currentToken}
+
+	if !p.advanceIfNextToken(tokens.STRING) {
+		p.addError("Missing the name of the test")
+		return nil
+	}
+
+	stmt.Name = ast.NewString(p.currentToken)
+
+	if !p.advanceIfNextToken(tokens.LBRAC) {
+		p.addError("Missing '{' on test statement")
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.currentToken}
+
+	if !p.advanceIfNextToken(tokens.STRING) {
+		p.addError("Missing the path of the import statement")
+		return nil
+	}
+
+	stmt.Path = ast.NewString(p.currentToken)
+
+	if !p.advanceIfNextToken(tokens.AS) {
+		p.addError("Missing 'como' on import statement")
+		return nil
+	}
+
+	if !p.advanceIfNextToken(tokens.IDENT) {
+		p.addError("Missing the name of the imported module")
+		return nil
+	}
+
+	stmt.Name = ast.NewIdentifier(p.currentToken)
+
+	if p.nextToken.Type == tokens.SEMICOLON {
+		p.advanceToken()
+	}
+
+	return stmt
+}
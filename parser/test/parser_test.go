@@ -1,10 +1,12 @@
 package test
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/sl2.0/ast"
+	"github.com/sl2.0/parser"
 )
 
 func TestFuncCall(t *testing.T) {
@@ -86,3 +88,231 @@ func TestFuncCall(t *testing.T) {
 		}
 	}
 }
+
+func TestInterpolatedString(t *testing.T) {
+	p := generateProgram(t, `"Hola ${nombre}, tienes ${edad + 1} años"`)
+
+	if len(p.Statements) != 1 {
+		t.Fatalf("Number of statements found: %d", len(p.Statements))
+	}
+
+	stmt, ok := p.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Cannot convert statement to ast.ExpressionStatement")
+	}
+
+	exp, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("Cannot convert statement to ast.InterpolatedString")
+	}
+
+	expected := []string{
+		`String: Hola`,
+		`Identifier: nombre`,
+		`String: , tienes`,
+		`infix expression:
+ left:
+    Identifier: edad
+ operator: +
+ right:
+    Integer: 1`,
+		`String:  años`,
+	}
+
+	if len(exp.Parts) != len(expected) {
+		t.Fatalf("Expected %d parts. Got %v", len(expected), len(exp.Parts))
+	}
+
+	for i, v := range exp.Parts {
+		actual := strings.TrimSpace(v.ToString(0))
+		if actual != expected[i] {
+			t.Errorf("Expected:\n%s\nGot:\n%s", expected[i], actual)
+		}
+	}
+}
+
+func TestLexicalErrors(t *testing.T) {
+	p := parser.NewParser("var a = 0x;\nvar b = 12abc;")
+	p.ParseProgram()
+
+	expected := []string{
+		"Malformed number '0x': missing digits after the prefix (line 1, column 9)",
+		"Malformed number '12abc': identifiers cannot start with a digit (line 2, column 9)",
+	}
+
+	errors := p.Errors()
+	if len(errors) < len(expected) {
+		t.Fatalf("Expected at least %d errors. Got %v", len(expected), errors)
+	}
+
+	for i, msg := range expected {
+		if errors[i] != msg {
+			t.Errorf("Expected error: %s\n\tGot: %s", msg, errors[i])
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	p := generateProgram(t, `
+        var saludo = "hola ${nombre}";
+        func algo(a, b) {
+            si (a > b) { retorna !true; } sino { retorna a(b, 2) * -3; }
+        }
+        var f = func(x) { repetir 3 { var x = x + 1; } retorna x; };
+        f(1) == algo(1, 2) != false;
+        intentar { lanzar error.valor; } capturar (e) { e.mensaje; } finalmente { 1; }
+        importar "util.sl" como util;
+    `)
+
+	data, err := ast.EncodeJSON(p)
+	if err != nil {
+		t.Fatalf("Unexpected error encoding: %s", err)
+	}
+
+	decoded, err := ast.DecodeJSON(data)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding: %s", err)
+	}
+
+	if decoded.ToString(0) != p.ToString(0) {
+		t.Fatalf("Decoded program differs.\nExpected:\n%s\nGot:\n%s", p.ToString(0), decoded.ToString(0))
+	}
+}
+
+func TestInvalidStatementsAreDiscarded(t *testing.T) {
+	p := parser.NewParser("var a = 0x;\nvar b = 2;;\n")
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 1 {
+		t.Fatalf("Expected 1 error. Got %v", p.Errors())
+	}
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Expected 1 statement. Got %d", len(program.Statements))
+	}
+
+	testVar(t, program.Statements[0], "b", 2)
+
+	if line, column := program.Statements[0].Position(); line != 2 || column != 1 {
+		t.Errorf("Expected position 2:1. Got %d:%d", line, column)
+	}
+}
+
+func TestErrorLines(t *testing.T) {
+	testCases := []struct {
+		input     string
+		expected  []int
+		positions []int // line of every error, lexical errors first
+	}{
+		{input: "var a = 1\nvar b = 2", expected: nil, positions: nil},
+		// every error of a statement is counted once, with its remains
+		{input: "var = 3\nvar b = 2\nf(1 2)", expected: []int{1, 3}, positions: []int{1, 1, 3, 3}},
+		{input: "var a = 0x;\n1", expected: []int{1}, positions: []int{1}},
+		{input: "1\nvar a = @\n\"sin cerrar", expected: []int{2, 3}, positions: []int{2, 3}},
+	}
+
+	for _, tc := range testCases {
+		p := parser.NewParser(tc.input)
+		p.ParseProgram()
+
+		if lines := p.ErrorLines(); fmt.Sprint(lines) != fmt.Sprint(tc.expected) {
+			t.Errorf("%q: expected the lines %v. Got %v", tc.input, tc.expected, lines)
+		}
+		if positions := p.ErrorPositions(); fmt.Sprint(positions) != fmt.Sprint(tc.positions) {
+			t.Errorf("%q: expected the errors on lines %v. Got %v (%q)", tc.input, tc.positions, positions, p.Errors())
+		}
+	}
+}
+
+func TestTryStatement(t *testing.T) {
+	p := generateProgram(t, "intentar {\n    lanzar e.valor\n} capturar (e) {\n    1\n} finalmente {\n    2\n}\n")
+
+	if len(p.Statements) != 1 {
+		t.Fatalf("Expected 1 statement. Got %d", len(p.Statements))
+	}
+
+	stmt, ok := p.Statements[0].(*ast.TryStatement)
+	if !ok {
+		t.Fatalf("Expected a try statement. Got %T", p.Statements[0])
+	}
+
+	if stmt.Identifier.Value != "e" || stmt.Catch == nil || stmt.Finally == nil {
+		t.Fatalf("Expected catch and finally blocks. Got:\n%s", stmt.ToString(0))
+	}
+
+	throw, ok := stmt.Body.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("Expected a throw statement. Got %T", stmt.Body.Statements[0])
+	}
+
+	member, ok := throw.Value.(*ast.MemberExpression)
+	if !ok || member.Member.Value != "valor" || member.Object.TokenLiteral() != "e" {
+		t.Errorf("Expected the member expression e.valor. Got %s", throw.Value.ToString(0))
+	}
+
+	invalid := []string{
+		"intentar {\n    1\n}\n",
+		"intentar {\n    1\n} capturar {\n    2\n}\n",
+		"intentar {\n    1\n} capturar (e {\n    2\n}\n",
+		"lanzar\n",
+		"e.\n",
+	}
+
+	for _, input := range invalid {
+		pars := parser.NewParser(input)
+		pars.ParseProgram()
+		if !pars.HasErrors() {
+			t.Errorf("Expected errors parsing %q", input)
+		}
+	}
+}
+
+func TestTestStatement(t *testing.T) {
+	p := generateProgram(t, "prueba \"suma\" {\n    afirmar_igual(1 + 1, 2)\n}\n")
+
+	if len(p.Statements) != 1 {
+		t.Fatalf("Expected 1 statement. Got %d", len(p.Statements))
+	}
+
+	test, ok := p.Statements[0].(*ast.TestStatement)
+	if !ok {
+		t.Fatalf("Expected a test statement. Got %T", p.Statements[0])
+	}
+
+	if test.Name.Value != "suma" || len(test.Body.Statements) != 1 {
+		t.Errorf("Expected the test 'suma' with 1 statement. Got:\n%s", test.ToString(0))
+	}
+
+	for _, input := range []string{"prueba {\n}\n", "prueba \"x\"\n"} {
+		pars := parser.NewParser(input)
+		pars.ParseProgram()
+		if !pars.HasErrors() {
+			t.Errorf("Expected errors parsing %q", input)
+		}
+	}
+}
+
+func TestImportStatement(t *testing.T) {
+	p := generateProgram(t, "importar \"lib/util.sl\" como util;\nutil.doble(2)\n")
+
+	if len(p.Statements) != 2 {
+		t.Fatalf("Expected 2 statements. Got %d", len(p.Statements))
+	}
+
+	stmt, ok := p.Statements[0].(*ast.ImportStatement)
+	if !ok {
+		t.Fatalf("Expected an import statement. Got %T", p.Statements[0])
+	}
+
+	if stmt.Path.Value != "lib/util.sl" || stmt.Name.Value != "util" {
+		t.Errorf("Expected the import of 'lib/util.sl' as util. Got:\n%s", stmt.ToString(0))
+	}
+
+	for _, input := range []string{"importar util\n", "importar \"util.sl\"\n", "importar \"util.sl\" como\n"} {
+		pars := parser.NewParser(input)
+		pars.ParseProgram()
+		if !pars.HasErrors() {
+			t.Errorf("Expected errors parsing %q", input)
+		}
+	}
+}
@@ -19,6 +19,34 @@ func (p *Parser) HasErrors() bool {
 	return len(p.errors) != 0
 }
 
+// Returns the line where every error of Errors() was found, in the same order
+func (p *Parser) ErrorPositions() []int {
+	return p.errorPositions
+}
+
+// registers an error at the line of the current token
+func (p *Parser) addError(msg string) {
+	p.addErrorAt(p.currentToken.Line, msg)
+}
+
+func (p *Parser) addErrorAt(line int, msg string) {
+	p.errors = append(p.errors, msg)
+	p.errorPositions = append(p.errorPositions, line)
+}
+
+// Lines where the statements with lexical or syntax errors start, in order and without
+// duplicates. The statements found after an error on the same line are remains of the
+// failed one.
+func (p *Parser) ErrorLines() []int {
+	return p.errorLines
+}
+
+func (p *Parser) addErrorLine(line int) {
+	if n := len(p.errorLines); n == 0 || p.errorLines[n-1] != line {
+		p.errorLines = append(p.errorLines, line)
+	}
+}
+
 func (p *Parser) registerInfixFn(t tokens.TokenType, f infixFn) {
 	p.infixParseFns[t] = f
 }
@@ -27,6 +55,14 @@ func (p *Parser) registerPrefixFn(t tokens.TokenType, f prefixFn) {
 	p.prefixParseFns[t] = f
 }
 
+// Steps over the line breaks found at the current token. Used after tokens which
+// cannot end an expression (like operators), so expressions can span many lines.
+func (p *Parser) skipLineBreaks() {
+	for p.curTokenIs(tokens.LINEBREAK) {
+		p.advanceToken()
+	}
+}
+
 // returns the precedence lvl of the current token
 func (p *Parser) curPrecendence() int {
 	value, ok := precedences[string(p.currentToken.Type)]
@@ -66,7 +102,7 @@ func (p *Parser) advanceIfNextToken(expTy tokens.TokenType) bool {
 	}
 
 	msg := fmt.Sprintf("Expected '%s'. Got %s", expTy, p.nextToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addErrorAt(p.nextToken.Line, msg)
 
 	return false
 }
@@ -78,7 +114,7 @@ func (p *Parser) advanceIfCurToken(expTy tokens.TokenType) bool {
 	}
 
 	msg := fmt.Sprintf("Expected '%s'. Got %s", expTy, p.currentToken.Literal)
-	p.errors = append(p.errors, msg)
+	p.addError(msg)
 
 	return false
 }
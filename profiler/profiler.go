@@ -0,0 +1,201 @@
+package profiler
+
+/*
+Profiler measures the time spent on the functions of a program. It traces the calls of
+the evaluator, recording for every function its number of calls, its inclusive time
+(with the functions it calls) and its self time, and for every call path (like
+"main;fibonacci;fibonacci") its self time.
+
+The program itself is the "main" function. Its measure starts with the first traced
+event and ends when a report is written, so the reports include every program evaluated
+with the profiler until then.
+
+Builtin functions are not traced, so their time is part of the self time of the
+function calling them.
+*/
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sl2.0/ast"
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/objects"
+)
+
+// Measures of a function
+type Function struct {
+	Name  string
+	Calls int
+
+	// time of its calls, counting only once the calls made by recursion
+	Total time.Duration
+	// time of its calls without the one of the functions they call
+	Self time.Duration
+}
+
+// A call which has not returned yet
+type activeCall struct {
+	function *Function
+	path     string // names of the calls on the stack, separated by ";"
+	start    time.Time
+	children time.Duration // inclusive time of the calls made by this one
+}
+
+type Profiler struct {
+	evaluator.NopTracer
+
+	now func() time.Time // replaced on the tests
+
+	functions map[string]*Function
+	paths     map[string]time.Duration // self time of every call path
+	stack     []*activeCall
+	active    map[string]int // calls on the stack of every function (for recursions)
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		now:       time.Now,
+		functions: map[string]*Function{},
+		paths:     map[string]time.Duration{},
+		active:    map[string]int{},
+	}
+}
+
+func (p *Profiler) OnStatement(e *evaluator.Evaluator, stmt ast.Statement) {
+	p.startMain()
+}
+
+func (p *Profiler) OnCall(e *evaluator.Evaluator, frame *evaluator.Frame) {
+	p.startMain()
+	p.push(frame.Function)
+}
+
+func (p *Profiler) OnReturn(e *evaluator.Evaluator, frame *evaluator.Frame, result objects.Object) {
+	// the main call is only closed by the reports
+	if len(p.stack) > 1 {
+		p.pop()
+	}
+}
+
+func (p *Profiler) startMain() {
+	if len(p.stack) == 0 {
+		p.push(evaluator.MainFrame)
+	}
+}
+
+func (p *Profiler) push(name string) {
+	function, ok := p.functions[name]
+	if !ok {
+		function = &Function{Name: name}
+		p.functions[name] = function
+	}
+	function.Calls++
+
+	path := name
+	if len(p.stack) > 0 {
+		path = p.stack[len(p.stack)-1].path + ";" + name
+	}
+
+	p.active[name]++
+	p.stack = append(p.stack, &activeCall{function: function, path: path, start: p.now()})
+}
+
+func (p *Profiler) pop() {
+	call := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+
+	elapsed := p.now().Sub(call.start)
+	self := elapsed - call.children
+
+	call.function.Self += self
+	p.paths[call.path] += self
+
+	p.active[call.function.Name]--
+	if p.active[call.function.Name] == 0 {
+		call.function.Total += elapsed
+	}
+
+	if len(p.stack) > 0 {
+		p.stack[len(p.stack)-1].children += elapsed
+	}
+}
+
+// Ends the calls which did not return, like the main one or the ones stopped by an exit
+func (p *Profiler) finish() {
+	for len(p.stack) > 0 {
+		p.pop()
+	}
+}
+
+// Returns the measures of every function, sorted by self time
+func (p *Profiler) Functions() []Function {
+	p.finish()
+
+	functions := make([]Function, 0, len(p.functions))
+	for _, function := range p.functions {
+		functions = append(functions, *function)
+	}
+
+	sort.Slice(functions, func(i, j int) bool {
+		if functions[i].Self != functions[j].Self {
+			return functions[i].Self > functions[j].Self
+		}
+		return functions[i].Name < functions[j].Name
+	})
+
+	return functions
+}
+
+// Writes the call paths on the folded stacks format read by the flame graph tools: a
+// line per path with its self time in microseconds, like "main;f;g 1500"
+func (p *Profiler) WriteFolded(w io.Writer) error {
+	p.finish()
+
+	paths := make([]string, 0, len(p.paths))
+	for path := range p.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if _, err := fmt.Fprintf(w, "%s %d\n", path, p.paths[path].Microseconds()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Writes a table with the measures of every function, sorted by self time
+func (p *Profiler) WriteSummary(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "Calls\tTotal\tSelf\tSelf %\t Function")
+
+	functions := p.Functions()
+
+	var total time.Duration
+	for _, function := range functions {
+		total += function.Self
+	}
+
+	for _, function := range functions {
+		percentage := 0.0
+		if total > 0 {
+			percentage = float64(function.Self) * 100 / float64(total)
+		}
+
+		fmt.Fprintf(table, "%d\t%s\t%s\t%.1f%%\t %s\n",
+			function.Calls, round(function.Total), round(function.Self), percentage, function.Name)
+	}
+
+	return table.Flush()
+}
+
+// Rounds the durations of the summary to microseconds
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,106 @@
+package profiler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/objects"
+)
+
+const program = `func fib(n) {
+    si (n < 2) {
+        retorna n
+    }
+    retorna fib(n - 1) + fib(n - 2)
+}
+
+func doble(n) {
+    retorna fib(n) * 2
+}
+
+doble(3)`
+
+// Profiles the program with a clock which advances a millisecond on every reading
+func profile(t *testing.T, src string) *Profiler {
+	p := NewProfiler()
+
+	clock := time.Unix(0, 0)
+	p.now = func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+
+	ev := evaluator.NewFromInput(src)
+	if ev == nil {
+		t.Fatalf("cannot parse the program")
+	}
+	ev.SetTracer(p)
+	ev.EvalProgram(objects.NewStorage())
+
+	return p
+}
+
+func TestFunctions(t *testing.T) {
+	functions := profile(t, program).Functions()
+
+	// fib(3) calls fib(2) and fib(1), and fib(2) calls fib(1) and fib(0)
+	expected := map[string]Function{
+		"fib":   {Name: "fib", Calls: 5, Total: 9 * time.Millisecond, Self: 9 * time.Millisecond},
+		"doble": {Name: "doble", Calls: 1, Total: 11 * time.Millisecond, Self: 2 * time.Millisecond},
+		"main":  {Name: "main", Calls: 1, Total: 13 * time.Millisecond, Self: 2 * time.Millisecond},
+	}
+
+	if len(functions) != len(expected) {
+		t.Fatalf("expected %d functions. Got %v", len(expected), functions)
+	}
+
+	if functions[0].Name != "fib" {
+		t.Errorf("expected fib to have the highest self time. Got %v", functions)
+	}
+
+	for _, function := range functions {
+		if function != expected[function.Name] {
+			t.Errorf("expected %+v. Got %+v", expected[function.Name], function)
+		}
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	var out bytes.Buffer
+	if err := profile(t, program).WriteFolded(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"main 2000",
+		"main;doble 2000",
+		"main;doble;fib 3000",
+		"main;doble;fib;fib 4000",
+		"main;doble;fib;fib;fib 2000",
+	}
+
+	if strings.TrimSpace(out.String()) != strings.Join(expected, "\n") {
+		t.Errorf("expected the folded stacks:\n%s\nGot:\n%s", strings.Join(expected, "\n"), out.String())
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	var out bytes.Buffer
+	if err := profile(t, program).WriteSummary(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected a header and 3 functions. Got:\n%s", out.String())
+	}
+
+	fields := strings.Fields(lines[1])
+	expected := []string{"5", "9ms", "9ms", "69.2%", "fib"}
+	if strings.Join(fields, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected the row %v. Got %v", expected, fields)
+	}
+}
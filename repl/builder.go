@@ -1,15 +1,20 @@
 package repl
 
 import (
+	"context"
 	"io"
 	"os"
 
 	"github.com/chzyer/readline"
-	"github.com/sl2.0/objects"
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/tokens"
 )
 
 type ReplBuilder struct {
 	repl Repl
+
+	historyFile    string
+	transcriptFile io.Writer
 }
 
 func NewReplBuilder() ReplBuilder {
@@ -18,10 +23,10 @@ func NewReplBuilder() ReplBuilder {
 			inFile:      os.Stdin,
 			outFile:     os.Stdout,
 			errFile:     os.Stderr,
-			env:         objects.NewStorage(),
 			mode:        EVAL,
 			interactive: false,
 			maxTime:     40000,
+			keywords:    tokens.Spanish,
 		},
 	}
 }
@@ -46,29 +51,111 @@ func (r ReplBuilder) WithMode(mode mode) ReplBuilder {
 	return r
 }
 
+// Output format of the lexer and parser modes
+func (r ReplBuilder) WithFormat(format format) ReplBuilder {
+	r.repl.format = format
+	return r
+}
+
 // Timeout in miliseconds for max execution time for evaluation
 func (r ReplBuilder) WithTimeout(miliseconds int64) ReplBuilder {
 	r.repl.maxTime = miliseconds
 	return r
 }
 
+// Stops the evaluations when the context is done, instead of ending the whole program
+// after the max execution time. Used by callers which run many programs, so they
+// decide what to do with the ones which take too long.
+func (r ReplBuilder) WithContext(ctx context.Context) ReplBuilder {
+	r.repl.ctx = ctx
+	return r
+}
+
+// Keyword set used when the input does not select one with a pragma
+func (r ReplBuilder) WithKeywords(keywords *tokens.KeywordSet) ReplBuilder {
+	r.repl.keywords = keywords
+	return r
+}
+
+// Arguments for the script, like the ones given after its file on the command line
+func (r ReplBuilder) WithArgs(args []string) ReplBuilder {
+	r.repl.args = args
+	return r
+}
+
+// Limits of the resources used by every input (zero values disable a limit)
+func (r ReplBuilder) WithLimits(limits evaluator.Limits) ReplBuilder {
+	r.repl.limits = limits
+	return r
+}
+
+// Restricts the environment variables readable by the programs to the given names. A
+// nil list allows every variable.
+func (r ReplBuilder) WithAllowedEnv(names []string) ReplBuilder {
+	r.repl.allowedEnv = names
+	return r
+}
+
+// File of the input, used to resolve the relative paths of its imports (the working
+// directory is used without a file)
+func (r ReplBuilder) WithFile(path string) ReplBuilder {
+	r.repl.path = path
+	return r
+}
+
+// Tracer of the evaluations, like a profiler
+func (r ReplBuilder) WithTracer(tracer evaluator.Tracer) ReplBuilder {
+	r.repl.tracer = tracer
+	return r
+}
+
+// Evaluates every statement of the input independently, reporting the result of each
+// one. Only used on non interactive eval mode.
+func (r ReplBuilder) EachStatement() ReplBuilder {
+	r.repl.each = true
+	return r
+}
+
+// File where the history of the interactive mode is kept between sessions. An empty
+// path disables the persistence.
+func (r ReplBuilder) WithHistoryFile(path string) ReplBuilder {
+	r.historyFile = path
+	return r
+}
+
+// Records every input of the session, with its output and errors, on the given file
+func (r ReplBuilder) WithTranscript(file io.Writer) ReplBuilder {
+	r.transcriptFile = file
+	return r
+}
+
 func (r ReplBuilder) Interactive() ReplBuilder {
 	r.repl.interactive = true
 	return r
 }
 
-func (b ReplBuilder) Build() Repl {
+func (b ReplBuilder) Build() *Repl {
+	r := &b.repl
+	r.resetSession()
+
+	if b.transcriptFile != nil {
+		r.transcript = newTranscript(b.transcriptFile, r)
+	}
+
 	rl, err := readline.NewEx(&readline.Config{
 		Prompt:          ">>> ",
 		InterruptPrompt: "^C",
 		EOFPrompt:       "exit",
-		Stdin:           b.repl.inFile,
+		HistoryFile:     b.historyFile,
+		Stdin:           r.inFile,
+		AutoComplete:    completer{repl: r},
+		Painter:         highlighter{repl: r},
 	})
 	if err != nil {
 		panic(err.Error())
 	}
 
-	b.repl.rlInstance = rl
+	r.rlInstance = rl
 
-	return b.repl
+	return r
 }
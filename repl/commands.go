@@ -0,0 +1,134 @@
+package repl
+
+/*
+Meta-commands of the interactive mode. Commands start with ':' and are handled
+before the input reaches the language parser, so they never conflict with it.
+*/
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sl2.0/debugger"
+)
+
+type command struct {
+	name string
+	args string
+	help string
+	run  func(r *Repl, arg string)
+}
+
+// initialized on init() because ":help" needs to list every command
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: ":help", help: "Show this help", run: (*Repl).cmdHelp},
+		{name: ":env", help: "List the variables and functions of the session", run: (*Repl).cmdEnv},
+		{name: ":reset", help: "Remove every definition of the session", run: (*Repl).cmdReset},
+		{name: ":load", args: "<file>", help: "Evaluate a file in the current session", run: (*Repl).cmdLoad},
+		{name: ":save", args: "<file>", help: "Write the definitions of the session to a file", run: (*Repl).cmdSave},
+		{name: ":ast", args: "<expr>", help: "Show the AST of an expression", run: (*Repl).cmdAst},
+		{name: ":tokens", args: "<expr>", help: "Show the tokens of an expression", run: (*Repl).cmdTokens},
+		{name: ":time", args: "<expr>", help: "Evaluate an expression and show the elapsed time", run: (*Repl).cmdTime},
+	}
+}
+
+func isCommand(input string) bool {
+	return strings.HasPrefix(strings.TrimSpace(input), ":")
+}
+
+func (r *Repl) runCommand(input string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(input), " ")
+	arg = strings.TrimSpace(arg)
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		if cmd.args != "" && arg == "" {
+			fmt.Fprintf(r.errFile, "Usage: %s %s\n", cmd.name, cmd.args)
+			return
+		}
+
+		cmd.run(r, arg)
+		return
+	}
+
+	fmt.Fprintf(r.errFile, "Unknown command '%s'. Type :help to list the commands\n", name)
+}
+
+func (r *Repl) cmdHelp(string) {
+	for _, cmd := range commands {
+		usage := strings.TrimSpace(cmd.name + " " + cmd.args)
+		fmt.Fprintf(r.outFile, "  %-16s %s\n", usage, cmd.help)
+	}
+	fmt.Fprintf(r.outFile, "  %-16s %s\n", "exit", "Close the REPL")
+}
+
+func (r *Repl) cmdEnv(string) {
+	names := r.env.Identifiers()
+	if len(names) == 0 {
+		fmt.Fprintln(r.outFile, "No definitions")
+		return
+	}
+
+	for _, name := range names {
+		value, _ := r.env.Get(name)
+		fmt.Fprintf(r.outFile, "%s = %s\n", name, debugger.Describe(value))
+	}
+}
+
+func (r *Repl) cmdReset(string) {
+	r.resetSession()
+	fmt.Fprintln(r.outFile, "Session cleared")
+}
+
+func (r *Repl) cmdLoad(file string) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(r.errFile, "Error opening file: "+err.Error())
+		return
+	}
+
+	r.runWithTimeout(func() {
+		r.execute(string(content))
+	})
+}
+
+func (r *Repl) cmdSave(file string) {
+	content := strings.Join(r.definitions, "\n") + "\n"
+
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		fmt.Fprintln(r.errFile, "Error writing file: "+err.Error())
+		return
+	}
+
+	fmt.Fprintf(r.outFile, "%d definitions saved to %s\n", len(r.definitions), file)
+}
+
+func (r *Repl) cmdAst(expr string) {
+	format := r.format
+	r.format = TEXT
+	r.parse(expr)
+	r.format = format
+}
+
+func (r *Repl) cmdTokens(expr string) {
+	format := r.format
+	r.format = TEXT
+	r.lexe(expr)
+	r.format = format
+}
+
+func (r *Repl) cmdTime(expr string) {
+	start := time.Now()
+	r.runWithTimeout(func() {
+		r.execute(expr)
+	})
+	fmt.Fprintf(r.outFile, "Elapsed time: %v\n", time.Since(start))
+}
@@ -0,0 +1,72 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/sl2.0/evaluator"
+)
+
+// Tab completion of keywords, identifiers of the session, builtins and meta-commands
+type completer struct {
+	repl *Repl
+}
+
+// Implements readline.AutoCompleter. Returns the missing part of every candidate which
+// starts with the word under the cursor, and the length of that word.
+func (c completer) Do(line []rune, pos int) ([][]rune, int) {
+	start := pos
+	for start > 0 && isWordRune(line[start-1]) {
+		start--
+	}
+
+	word := string(line[start:pos])
+
+	var candidates []string
+
+	// commands are only valid at the beginning of the input
+	if strings.TrimSpace(string(line[:start])) == "" && strings.HasPrefix(strings.TrimSpace(word), ":") {
+		for _, cmd := range commands {
+			candidates = append(candidates, cmd.name)
+		}
+	} else {
+		candidates = c.words()
+	}
+
+	var suffixes [][]rune
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) && candidate != word {
+			suffixes = append(suffixes, []rune(strings.TrimPrefix(candidate, word)))
+		}
+	}
+
+	return suffixes, len([]rune(word))
+}
+
+// every word of the language known by the session, sorted and without duplicates
+func (c completer) words() []string {
+	unique := map[string]bool{}
+
+	for _, keyword := range c.repl.keywords.Keywords {
+		unique[keyword.Literal] = true
+	}
+	for _, name := range c.repl.env.Identifiers() {
+		unique[name] = true
+	}
+	for _, name := range evaluator.BuiltinNames() {
+		unique[name] = true
+	}
+
+	words := make([]string, 0, len(unique))
+	for word := range unique {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	return words
+}
+
+func isWordRune(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_' || ch == ':'
+}
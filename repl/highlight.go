@@ -0,0 +1,88 @@
+package repl
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/tokens"
+)
+
+const (
+	colorNone    = "\033[0m"
+	colorKeyword = "\033[35m" // magenta
+	colorString  = "\033[32m" // green
+	colorNumber  = "\033[33m" // yellow
+	colorComment = "\033[90m" // gray
+)
+
+// Live syntax coloring of the line being edited, driven by the lexer
+type highlighter struct {
+	repl *Repl
+}
+
+// Implements readline.Painter
+func (h highlighter) Paint(line []rune, _ int) []rune {
+	if len(line) == 0 {
+		return line
+	}
+
+	colors := make([]string, len(line))
+	paint := func(from, length int, color string) {
+		for i := from; i < from+length && i < len(colors); i++ {
+			colors[i] = color
+		}
+	}
+
+	l := lexer.NewLexerWithKeywords(string(line), h.repl.keywords)
+	for token := l.NexToken(); token.Type != tokens.EOF; token = l.NexToken() {
+		// columns are counted in runes, starting from 1
+		start := token.Column - 1
+		length := utf8.RuneCountInString(token.Literal)
+
+		switch token.Type {
+		case tokens.STRING, tokens.INTERP_END:
+			paint(start, length+2, colorString) // quotes or "}" and quote
+		case tokens.INTERP_START, tokens.INTERP_MID:
+			paint(start, length+3, colorString) // quote or "}", and "${"
+		case tokens.NUMBER:
+			paint(start, length, colorNumber)
+		case tokens.IDENT, tokens.ILLEGAL:
+			if strings.HasPrefix(token.Literal, "\"") { // unterminated string
+				paint(start, length, colorString)
+			}
+		default:
+			if h.repl.keywords.Resolve(token.Literal) == token.Type {
+				paint(start, length, colorKeyword)
+			}
+		}
+	}
+
+	// comments are skipped by the lexer, so they are the "//" outside of strings
+	for i := 0; i+1 < len(line); i++ {
+		if colors[i] == "" && line[i] == '/' && line[i+1] == '/' {
+			for j := i; j < len(line) && line[j] != '\n'; j++ {
+				colors[j] = colorComment
+			}
+			break
+		}
+	}
+
+	var out strings.Builder
+	current := ""
+	for i, ch := range line {
+		if colors[i] != current {
+			if current != "" {
+				out.WriteString(colorNone)
+			}
+			out.WriteString(colors[i])
+			current = colors[i]
+		}
+		out.WriteRune(ch)
+	}
+	if current != "" {
+		out.WriteString(colorNone)
+	}
+
+	return []rune(out.String())
+}
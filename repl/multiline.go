@@ -0,0 +1,53 @@
+package repl
+
+import (
+	"strings"
+
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/tokens"
+)
+
+// tokens which cannot end a complete input, as they expect something after them
+var continuationTokens = map[tokens.TokenType]bool{
+	tokens.PLUS:     true,
+	tokens.MINUS:    true,
+	tokens.ASTERISC: true,
+	tokens.SLASH:    true,
+	tokens.BANG:     true,
+	tokens.ASIGN:    true,
+	tokens.EQUALS:   true,
+	tokens.NOTEQUAL: true,
+	tokens.LT:       true,
+	tokens.GT:       true,
+	tokens.COMMA:    true,
+	tokens.COLON:    true,
+}
+
+// Reports if the input needs more lines to be complete: it has unbalanced brackets or
+// parentheses, an unterminated string or it ends with an operator.
+func (r *Repl) isIncomplete(input string) bool {
+	l := lexer.NewLexerWithKeywords(input, r.keywords)
+
+	depth := 0
+	var last tokens.Token
+
+	for token := l.NexToken(); token.Type != tokens.EOF; token = l.NexToken() {
+		switch token.Type {
+		case tokens.LBRAC, tokens.LPAR, tokens.INTERP_START:
+			depth++
+		case tokens.RBRAC, tokens.RPAR, tokens.INTERP_END:
+			depth--
+		case tokens.ILLEGAL:
+			// the lexer keeps the opening quote of unterminated strings
+			if strings.HasPrefix(token.Literal, "\"") {
+				return true
+			}
+		}
+
+		if token.Type != tokens.LINEBREAK {
+			last = token
+		}
+	}
+
+	return depth > 0 || continuationTokens[last.Type]
+}
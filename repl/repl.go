@@ -2,15 +2,19 @@ package repl
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/chzyer/readline"
 
+	"github.com/sl2.0/ast"
 	"github.com/sl2.0/evaluator"
 	"github.com/sl2.0/lexer"
 	"github.com/sl2.0/objects"
@@ -26,29 +30,85 @@ const (
 	LEXER
 )
 
+type format int
+
+// Output formats for the lexer and parser modes
+const (
+	TEXT format = iota
+	JSON
+)
+
+// Exit statuses returned by Run. The values follow the conventions of sysexits.h and
+// the timeout command.
+const (
+	EXIT_OK            = 0
+	EXIT_FAILURE       = 1   // the input could not be read
+	EXIT_FAILED_STMTS  = 3   // some statement failed when evaluating each one independently
+	EXIT_SYNTAX_ERROR  = 65  // the input has lexical or syntax errors
+	EXIT_RUNTIME_ERROR = 70  // the evaluation failed
+	EXIT_LIMIT         = 71  // an execution limit (steps, memory or output) was exceeded
+	EXIT_TIMEOUT       = 124 // the max execution time was reached
+)
+
 type Repl struct {
 	inFile  io.ReadCloser
 	outFile io.WriteCloser
 	errFile io.WriteCloser
 
 	mode        mode
+	format      format
 	interactive bool
+	each        bool // evaluate every statement of a file independently
 
 	rlInstance *readline.Instance
-	env        *objects.Storage
+	env        *objects.Storage // definitions of the user
+	results    *objects.Storage // values of previous evaluations (_, _1, _2 ...)
+	nResults   int
+	keywords   *tokens.KeywordSet
 
 	maxTime int64
+	ctx     context.Context // stops the evaluations instead of maxTime (nil to disable)
+	limits  evaluator.Limits
+	budget  *evaluator.Budget // resources used by the current input
+
+	// arguments for the script, given after its file on the command line
+	args []string
+	// environment variables readable by the programs (nil allows every variable)
+	allowedEnv []string
+
+	// set when a program calls "salir", which ends the repl with the given status
+	exited   bool
+	exitCode int
+
+	// inputs of the session which defined variables or functions (used by ":save")
+	definitions []string
+
+	// records every input with its output (nil if disabled)
+	transcript *transcript
+
+	tracer evaluator.Tracer // receives the events of the evaluations (nil if disabled)
+
+	path    string             // file of the input, used to resolve its imports
+	modules *evaluator.Modules // modules imported on the session
 }
 
-func (r Repl) Run() {
+// Runs the repl until the input ends. Returns the exit status of the execution (one
+// of the EXIT_* constants).
+func (r *Repl) Run() int {
 	if r.interactive {
 		r.runInteractively()
-	} else {
-		r.runFromFile()
+		return r.exitCode
+	}
+
+	status := r.runFromFile()
+	if r.exited {
+		return r.exitCode
 	}
+
+	return status
 }
 
-func (r Repl) runInteractively() {
+func (r *Repl) runInteractively() {
 	defer r.rlInstance.Close()
 	buffer := ""
 	for {
@@ -57,6 +117,7 @@ func (r Repl) runInteractively() {
 			if buffer != "" {
 				buffer = "" // Clear buffer
 				fmt.Fprintln(r.errFile, "Kill Signal Recieved")
+				r.rlInstance.SetPrompt(">>> ")
 				continue
 			}
 			break
@@ -64,20 +125,32 @@ func (r Repl) runInteractively() {
 			break
 		}
 
-		// Detect multi-line input with Shift+Enter or empty buffer with Enter
+		// Explicit multi-line input with a trailing backslash
 		if strings.HasSuffix(line, "\\") {
 			buffer += strings.TrimSuffix(line, "\\") + "\n"
 			r.rlInstance.SetPrompt("... ") // Change prompt for multi-line input
 			continue
 		}
 
+		// An empty line forces the submission of an incomplete input
+		forced := line == "" && buffer != ""
+
 		buffer += line
 		if buffer == "exit" { // Exit condition
 			break
 		}
 
-		// Run or panic
-		r.evaluateWithTimeout(buffer)
+		// Wait for the rest of the input (unclosed brackets, strings, etc)
+		if !forced && !isCommand(buffer) && r.isIncomplete(buffer) {
+			buffer += "\n"
+			r.rlInstance.SetPrompt("... ")
+			continue
+		}
+
+		r.submit(buffer)
+		if r.exited {
+			break
+		}
 
 		// Reset for next command
 		buffer = ""
@@ -85,7 +158,7 @@ func (r Repl) runInteractively() {
 	}
 }
 
-func (r Repl) runFromFile() {
+func (r *Repl) runFromFile() int {
 	inReader := bufio.NewReader(r.inFile)
 
 	var input strings.Builder
@@ -94,8 +167,8 @@ func (r Repl) runFromFile() {
 		if err != nil {
 			// If the error if not from EOF
 			if err != io.EOF {
-				fmt.Fprintf(r.errFile, "Error reading input: %v", err)
-				return
+				fmt.Fprintf(r.errFile, "Error reading input: %v\n", err)
+				return EXIT_FAILURE
 			}
 
 			if len(str) > 0 {
@@ -106,22 +179,70 @@ func (r Repl) runFromFile() {
 		input.WriteString(str)
 	}
 
-	r.evaluateWithTimeout(input.String())
+	if r.each && r.mode == EVAL {
+		// every statement shares the same budget
+		r.budget = evaluator.NewBudget(r.limits)
+		status := EXIT_OK
+		r.runWithTimeout(func() {
+			status = r.executeEach(input.String())
+		})
+
+		return status
+	}
+
+	return r.submit(input.String())
+}
+
+// Runs a complete input (a meta-command or code) and records it on the transcript.
+// Returns the exit status of the input.
+func (r *Repl) submit(input string) int {
+	r.transcript.begin()
+
+	r.budget = evaluator.NewBudget(r.limits)
+
+	status := EXIT_OK
+	if isCommand(input) {
+		r.runCommand(input)
+	} else {
+		// Run or panic
+		status = r.evaluateWithTimeout(input)
+	}
+
+	r.transcript.record(input)
+
+	return status
 }
 
 // Evaluate results in a separate subrutine. If the max-timeout is reached
 // then kill the entire program.
-func (r Repl) evaluateWithTimeout(input string) {
-	c := make(chan struct{})
-	go func() {
+func (r *Repl) evaluateWithTimeout(input string) int {
+	status := EXIT_OK
+	r.runWithTimeout(func() {
 		switch r.mode {
 		case LEXER:
-			r.lexe(input)
+			status = r.lexe(input)
 		case PARSER:
-			r.parse(input)
+			status = r.parse(input)
 		default:
-			r.execute(input)
+			status = r.execute(input)
 		}
+	})
+
+	return status
+}
+
+// Runs the given function in a separate subrutine, killing the entire program if it
+// does not finish before the max-timeout. With a context, the evaluations are stopped
+// by it instead.
+func (r *Repl) runWithTimeout(run func()) {
+	if r.ctx != nil {
+		run()
+		return
+	}
+
+	c := make(chan struct{})
+	go func() {
+		run()
 		c <- struct{}{}
 	}()
 
@@ -132,55 +253,285 @@ func (r Repl) evaluateWithTimeout(input string) {
 	select {
 	case <-ctx.Done():
 		fmt.Fprint(r.errFile, "Timeout execution reached\n")
-		os.Exit(1)
+		os.Exit(EXIT_TIMEOUT)
 	case <-c:
 		break
 	}
 }
 
-func (r Repl) lexe(in string) {
-	l := lexer.NewLexer(in)
+// Returns an evaluator for the program with the arguments and environment of the repl
+func (r *Repl) newEvaluator(program *ast.Program) *evaluator.Evaluator {
+	ev := evaluator.NewFromProgram(program)
+	ev.SetArgs(r.args)
+	ev.SetAllowedEnv(r.allowedEnv)
+	ev.SetBudget(r.budget)
+	ev.SetOutput(r.outFile)
+	if r.ctx != nil {
+		ev.SetContext(r.ctx)
+	}
+	if r.tracer != nil {
+		ev.SetTracer(r.tracer)
+	}
+	if r.path != "" {
+		ev.SetPath(r.path)
+	}
+	ev.SetModules(r.modules)
+
+	return ev
+}
+
+// Returns a parser which uses the keyword set of the repl
+func (r *Repl) newParser(in string) *parser.Parser {
+	return parser.NewParserFromLexer(lexer.NewLexerWithKeywords(in, r.keywords))
+}
+
+// Prints the tokens of the input. Returns EXIT_SYNTAX_ERROR if it has lexical errors.
+func (r *Repl) lexe(in string) int {
+	l := lexer.NewLexerWithKeywords(in, r.keywords)
+
+	if r.format == JSON {
+		// the EOF token is included, so empty inputs still have a position
+		list := []tokens.Token{}
+		for token := l.NexToken(); ; token = l.NexToken() {
+			list = append(list, token)
+			if token.Type == tokens.EOF {
+				break
+			}
+		}
+
+		data, _ := json.Marshal(list)
+		r.writeJSON(data)
+		printErrors(r.errFile, l.Errors())
+		return lexicalStatus(l)
+	}
+
 	for token := l.NexToken(); token.Type != tokens.EOF; token = l.NexToken() {
 		fmt.Fprintf(r.outFile, "[Type: %v, Literal: '%v']\n", token.Type, token.Literal)
 	}
 	fmt.Fprintln(r.outFile)
+
+	return lexicalStatus(l)
+}
+
+func lexicalStatus(l *lexer.Lexer) int {
+	if len(l.Errors()) != 0 {
+		return EXIT_SYNTAX_ERROR
+	}
+
+	return EXIT_OK
 }
 
-func (r Repl) parse(in string) {
+// Prints the AST of the input. Returns EXIT_SYNTAX_ERROR if it has syntax errors.
+func (r *Repl) parse(in string) int {
 	// Parse and output results
-	p := parser.NewParser(in)
+	p := r.newParser(in)
 	program := p.ParseProgram()
 
 	if len(p.Errors()) != 0 {
 		printErrors(r.errFile, p.Errors()) // Print errors if any
+		return EXIT_SYNTAX_ERROR
+	}
+
+	if r.format == JSON {
+		data, err := ast.EncodeJSON(program)
+		if err != nil {
+			fmt.Fprintln(r.errFile, "Cannot encode the AST: "+err.Error())
+			return EXIT_FAILURE
+		}
+		r.writeJSON(data)
 	} else {
 		fmt.Fprintf(r.outFile, "%v", program.ToString(0))
 		fmt.Fprintln(r.outFile)
 	}
+
+	return EXIT_OK
+}
+
+// writes an indented JSON document followed by a line break
+func (r *Repl) writeJSON(data []byte) {
+	var out bytes.Buffer
+	json.Indent(&out, data, "", "  ")
+	out.WriteString("\n")
+	out.WriteTo(r.outFile)
 }
 
-func (r Repl) execute(in string) {
-	// Parse and evaluate the complete input
-	p := parser.NewParser(in)
+// Parses and evaluates the complete input. Returns the exit status of the evaluation.
+func (r *Repl) execute(in string) int {
+	p := r.newParser(in)
 	program := p.ParseProgram()
 
 	if len(p.Errors()) != 0 {
 		printErrors(r.errFile, p.Errors())
+		return EXIT_SYNTAX_ERROR
+	}
+
+	ev := r.newEvaluator(program)
+	evaluated := ev.EvalProgram(r.env)
+
+	if ev.HasErrors() {
+		printErrors(r.errFile, ev.Errors())
+		return EXIT_RUNTIME_ERROR
+	}
+
+	if exit, ok := evaluated.(*objects.ExitObject); ok {
+		r.exit(exit)
+		return r.exitCode
+	}
+
+	if evaluated != nil && r.interactive && !isError(evaluated) {
+		fmt.Fprintf(r.outFile, "%s = %s\n", r.storeResult(evaluated), evaluated.Inspect())
+	} else if evaluated != nil {
+		fmt.Fprintln(r.outFile, evaluated.Inspect())
+		printStackTrace(r.outFile, evaluated)
 	} else {
-		ev := evaluator.NewFromProgram(program)
+		fmt.Fprintln(r.outFile, "No returned values")
+	}
+
+	if isError(evaluated) {
+		return errorStatus(evaluated)
+	}
+
+	if hasDefinitions(program) {
+		r.definitions = append(r.definitions, in)
+	}
+
+	return EXIT_OK
+}
+
+// Evaluates every top-level statement independently, so an error does not stop the
+// execution of the rest. The result or error of every statement is reported with its
+// line, followed by the number of failed statements. Returns EXIT_FAILED_STMTS if any
+// statement failed (including the ones with syntax errors).
+func (r *Repl) executeEach(in string) int {
+	p := r.newParser(in)
+	program := p.ParseProgram()
+
+	// statements with syntax errors are not part of the program, so they are
+	// reported and counted here
+	lines := p.ErrorPositions()
+	for i, msg := range p.Errors() {
+		fmt.Fprintf(r.errFile, "line %d: error: %s\n", lines[i], msg)
+	}
+	errorLines := p.ErrorLines()
+	failed := len(errorLines)
+
+	// only the statements which run are counted, so the ones after an exit are not
+	ran, skipped := 0, 0
+	for _, stmt := range program.Statements {
+		line, _ := stmt.Position()
+
+		// the rest of a line with syntax errors could depend on the failed statement
+		if slices.Contains(errorLines, line) {
+			skipped++
+			fmt.Fprintf(r.outFile, "line %d: skipped, the line has syntax errors\n", line)
+			continue
+		}
+
+		ran++
+		ev := r.newEvaluator(&ast.Program{Statements: []ast.Statement{stmt}})
 		evaluated := ev.EvalProgram(r.env)
 
-		if ev.HasErrors() {
-			printErrors(r.errFile, ev.Errors())
-			return
+		if exit, ok := evaluated.(*objects.ExitObject); ok {
+			// the rest of the statements are not evaluated
+			r.exit(exit)
+			fmt.Fprintf(r.outFile, "line %d: exit with status %d\n", line, exit.Code)
+			break
 		}
 
-		if evaluated != nil {
-			fmt.Fprintln(r.outFile, evaluated.Inspect())
-		} else {
-			fmt.Fprintln(r.outFile, "No returned values")
+		switch {
+		case ev.HasErrors():
+			failed++
+			for _, msg := range ev.Errors() {
+				fmt.Fprintf(r.errFile, "line %d: error: %s\n", line, msg)
+			}
+		case isError(evaluated):
+			failed++
+			fmt.Fprintf(r.errFile, "line %d: error: %s\n", line, evaluated.Inspect())
+			printStackTrace(r.errFile, evaluated)
+		case evaluated == nil:
+			fmt.Fprintf(r.outFile, "line %d: no returned values\n", line)
+		default:
+			fmt.Fprintf(r.outFile, "line %d: %s\n", line, evaluated.Inspect())
+		}
+
+		// the budget is shared, so the rest of the statements would fail too
+		if isError(evaluated) && errorStatus(evaluated) == EXIT_LIMIT {
+			break
 		}
 	}
+
+	summary := fmt.Sprintf("%d of %d statements failed", failed, ran+len(errorLines))
+	if skipped != 0 {
+		summary += fmt.Sprintf(", %d skipped", skipped)
+	}
+	fmt.Fprintln(r.outFile, summary)
+
+	if failed != 0 {
+		return EXIT_FAILED_STMTS
+	}
+
+	return EXIT_OK
+}
+
+func (r *Repl) exit(exit *objects.ExitObject) {
+	r.exited = true
+	r.exitCode = int(exit.Code)
+}
+
+// Creates the storage of a new session. The user definitions are enclosed by the
+// results of the previous evaluations, so results never shadow user definitions.
+func (r *Repl) resetSession() {
+	r.results = objects.NewStorage()
+	r.env, _ = objects.NewEnclosedStorage(r.results)
+	r.nResults = 0
+	r.definitions = nil
+	r.modules = evaluator.NewModules(r.keywords)
+}
+
+// Binds the value to "_" and to the next numbered result ("_1", "_2" ...). Returns the
+// numbered name.
+func (r *Repl) storeResult(value objects.Object) string {
+	r.nResults++
+	name := fmt.Sprintf("_%d", r.nResults)
+
+	r.results.Set(name, value)
+	r.results.Set("_", value)
+
+	return name
+}
+
+func errorStatus(err objects.Object) int {
+	switch err.(*objects.ErrorObject).Kind {
+	case objects.LIMIT_ERROR:
+		return EXIT_LIMIT
+	case objects.CANCEL_ERROR:
+		return EXIT_TIMEOUT
+	}
+
+	return EXIT_RUNTIME_ERROR
+}
+
+func isError(obj objects.Object) bool {
+	return obj != nil && obj.Type() == objects.ERROR_OBJ
+}
+
+// Reports if the program declares variables or functions at the top level
+func hasDefinitions(program *ast.Program) bool {
+	for _, stmt := range program.Statements {
+		switch stmt.(type) {
+		case *ast.VarStatement, *ast.FunctionStatement, *ast.ImportStatement:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Prints the stack trace of an error object, if it has one
+func printStackTrace(out io.Writer, obj objects.Object) {
+	if err, ok := obj.(*objects.ErrorObject); ok && len(err.Trace) > 0 {
+		fmt.Fprintln(out, err.StackTrace())
+	}
 }
 
 func printErrors(out io.Writer, errors []string) {
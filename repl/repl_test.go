@@ -0,0 +1,288 @@
+package repl
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Returns a repl writing its output and errors to the given buffers
+func newTestRepl(builder ReplBuilder, output, errors *bytes.Buffer) *Repl {
+	return builder.
+		WithStdout(nopWriteCloser{output}).
+		WithStderr(nopWriteCloser{errors}).
+		Build()
+}
+
+func TestTranscript(t *testing.T) {
+	testCases := []struct {
+		name        string
+		interactive bool
+		input       string
+		expected    string
+	}{
+		{name: "interactive", interactive: true, input: "1 + 2", expected: "_1 = 3\n"},
+		// piped inputs are read whole, with their line breaks
+		{name: "piped", interactive: false, input: "1 + 2\n", expected: "3\n"},
+	}
+
+	for _, tc := range testCases {
+		var transcript, output, errors bytes.Buffer
+
+		builder := NewReplBuilder().WithTranscript(&transcript)
+		if tc.interactive {
+			newTestRepl(builder.Interactive(), &output, &errors).submit(tc.input)
+		} else {
+			builder = builder.WithStdin(io.NopCloser(strings.NewReader(tc.input)))
+			newTestRepl(builder, &output, &errors).Run()
+		}
+
+		var entry TranscriptEntry
+		if err := json.Unmarshal(transcript.Bytes(), &entry); err != nil {
+			t.Fatalf("%s: invalid transcript: %v", tc.name, err)
+		}
+		if entry.Input != tc.input || entry.Output != tc.expected || entry.Interactive != tc.interactive {
+			t.Errorf("%s: expected the output %q. Got %+v", tc.name, tc.expected, entry)
+		}
+
+		// the transcript is replayed on the mode it was recorded
+		var report strings.Builder
+		differences, err := Replay(&transcript, &report, NewReplBuilder())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if differences != 0 {
+			t.Errorf("%s: expected no differences. Got:\n%s", tc.name, report.String())
+		}
+	}
+
+	// differences are reported with the recorded and the current output
+	entry := `{"input": "2 * 2", "output": "_1 = 5\n", "errors": "", "interactive": true}` + "\n"
+
+	var report strings.Builder
+	differences, err := Replay(strings.NewReader(entry), &report, NewReplBuilder())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Input 1 differs:\n    2 * 2\n  expected output:\n    _1 = 5\n  got:\n    _1 = 4\n"
+	if differences != 1 || report.String() != expected {
+		t.Errorf("expected the report:\n%s\nGot:\n%s", expected, report.String())
+	}
+}
+
+func TestIsIncomplete(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		// brackets and parentheses
+		{input: "var x = (1", expected: true},
+		{input: "func f() {", expected: true},
+		{input: "func f() {\n    retorna 1\n}", expected: false},
+		{input: "repetir 2 { si (true) { 1 }", expected: true},
+		{input: "(1 + 2) * 3", expected: false},
+		// strings
+		{input: `"hola`, expected: true},
+		{input: `"hola"`, expected: false},
+		{input: `"a ${x`, expected: true},
+		{input: `"a ${x} b"`, expected: false},
+		// trailing operators, even before a line break
+		{input: "1 +", expected: true},
+		{input: "var x =", expected: true},
+		{input: "f(1,", expected: true},
+		{input: "1 ==\n", expected: true},
+		{input: "1 + 2", expected: false},
+		{input: "", expected: false},
+	}
+
+	r := NewReplBuilder().Build()
+	for _, tc := range testCases {
+		if incomplete := r.isIncomplete(tc.input); incomplete != tc.expected {
+			t.Errorf("%q: expected incomplete %v. Got %v", tc.input, tc.expected, incomplete)
+		}
+	}
+}
+
+func TestCompleter(t *testing.T) {
+	var output, errors bytes.Buffer
+	r := newTestRepl(NewReplBuilder().Interactive(), &output, &errors)
+	r.submit("var contador = 1")
+
+	testCases := []struct {
+		line     string
+		pos      int // cursor position (the end of the line if negative)
+		expected []string
+		length   int
+	}{
+		{line: "conta", pos: -1, expected: []string{"dor"}, length: 5},
+		{line: "1 + conta", pos: -1, expected: []string{"dor"}, length: 5},
+		{line: "retor", pos: -1, expected: []string{"na"}, length: 5},
+		{line: "impri", pos: -1, expected: []string{"mir"}, length: 5},
+		{line: "conta + 1", pos: 5, expected: []string{"dor"}, length: 5},
+		// commands only at the beginning of the input
+		{line: ":re", pos: -1, expected: []string{"set"}, length: 3},
+		{line: ":t", pos: -1, expected: []string{"okens", "ime"}, length: 2},
+		{line: "1 :re", pos: -1, expected: nil, length: 3},
+		// complete words have no candidates
+		{line: "contador", pos: -1, expected: nil, length: 8},
+	}
+
+	for _, tc := range testCases {
+		line := []rune(tc.line)
+		pos := tc.pos
+		if pos < 0 {
+			pos = len(line)
+		}
+
+		suffixes, length := completer{repl: r}.Do(line, pos)
+
+		got := []string{}
+		for _, suffix := range suffixes {
+			got = append(got, string(suffix))
+		}
+		if strings.Join(got, ",") != strings.Join(tc.expected, ",") || length != tc.length {
+			t.Errorf("%q: expected %v (length %d). Got %v (length %d)", tc.line, tc.expected, tc.length, got, length)
+		}
+	}
+}
+
+func TestHighlighter(t *testing.T) {
+	keyword := func(s string) string { return colorKeyword + s + colorNone }
+	str := func(s string) string { return colorString + s + colorNone }
+	number := func(s string) string { return colorNumber + s + colorNone }
+	comment := func(s string) string { return colorComment + s + colorNone }
+
+	testCases := []struct {
+		line     string
+		expected string
+	}{
+		{line: "", expected: ""},
+		{line: "x + y", expected: "x + y"},
+		{line: "var x = 12", expected: keyword("var") + " x = " + number("12")},
+		{line: `imprimir("hola")`, expected: "imprimir(" + str(`"hola"`) + ")"},
+		{line: `"a ${x} b"`, expected: str(`"a ${`) + "x" + str(`} b"`)},
+		{line: `"sin cerrar`, expected: str(`"sin cerrar`)},
+		{line: "si (true) { 1 } // fin", expected: keyword("si") + " (" + keyword("true") + ") { " + number("1") + " } " + comment("// fin")},
+		// comment markers inside strings are part of the string
+		{line: `"//"`, expected: str(`"//"`)},
+	}
+
+	r := NewReplBuilder().Build()
+	for _, tc := range testCases {
+		if painted := string(highlighter{repl: r}.Paint([]rune(tc.line), 0)); painted != tc.expected {
+			t.Errorf("%q: expected %q. Got %q", tc.line, tc.expected, painted)
+		}
+	}
+}
+
+func TestCommands(t *testing.T) {
+	var output, errors bytes.Buffer
+	r := newTestRepl(NewReplBuilder().Interactive(), &output, &errors)
+
+	file := filepath.Join(t.TempDir(), "sesion.sl")
+
+	steps := []struct {
+		input  string
+		output string
+		errors string
+	}{
+		{input: ":env", output: "No definitions\n"},
+		{input: "var x = 2", output: "_1 = 2\n"},
+		{input: "func doble(n) {\n    retorna n * 2\n}"},
+		{input: ":env", output: "doble = func(n)\nx = 2\n"},
+		{input: ":save " + file, output: "2 definitions saved to " + file + "\n"},
+		{input: ":reset", output: "Session cleared\n"},
+		{input: ":env", output: "No definitions\n"},
+		{input: ":load " + file},
+		{input: ":env", output: "doble = func(n)\nx = 2\n"},
+		{input: ":time doble(x)", output: "_2 = 4\nElapsed time: "},
+		{input: ":load", errors: "Usage: :load <file>\n"},
+		{input: ":load " + file + ".nada", errors: "Error opening file: "},
+		{input: ":nada", errors: "Unknown command ':nada'. Type :help to list the commands\n"},
+	}
+
+	for _, step := range steps {
+		output.Reset()
+		errors.Reset()
+		r.submit(step.input)
+
+		// definitions print their values, and the times and system errors vary, so only
+		// the beginning of those outputs is checked
+		if step.output != "" && !strings.HasPrefix(output.String(), step.output) {
+			t.Errorf("%q: expected the output %q. Got %q", step.input, step.output, output.String())
+		}
+		if !strings.HasPrefix(errors.String(), step.errors) || (step.errors == "") != (errors.Len() == 0) {
+			t.Errorf("%q: expected the errors %q. Got %q", step.input, step.errors, errors.String())
+		}
+	}
+
+	saved, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "var x = 2\nfunc doble(n) {\n    retorna n * 2\n}\n"
+	if string(saved) != expected {
+		t.Errorf("expected the saved definitions %q. Got %q", expected, saved)
+	}
+}
+
+func TestExecuteEach(t *testing.T) {
+	testCases := []struct {
+		input  string
+		status int
+		output string
+		errors string
+	}{
+		{
+			input:  "var x = 1\nx + 1\n",
+			status: EXIT_OK,
+			output: "line 1: 1\nline 2: 2\n0 of 2 statements failed\n",
+		},
+		{
+			// errors do not stop the rest, and the remains of the failed statements are skipped
+			input:  "var = 3\n1 / 0\nf(1 2)\n3\n",
+			status: EXIT_FAILED_STMTS,
+			output: "line 1: skipped, the line has syntax errors\nline 3: skipped, the line has syntax errors\n" +
+				"line 3: skipped, the line has syntax errors\nline 4: 3\n3 of 4 statements failed, 3 skipped\n",
+			errors: "line 1: error: Expected 'IDENT'. Got ASIGN\nline 1: error: Not prefixFn found for: =\n" +
+				"line 3: error: Expected 'RPAR'. Got NUMBER\nline 3: error: Not prefixFn found for: )\n" +
+				"line 2: error: Division by zero\n  en main (línea 2)\n",
+		},
+		{
+			// valid statements after an error on the same line are not evaluated
+			input:  "var = 2; var y = 3\ny\n",
+			status: EXIT_FAILED_STMTS,
+			output: "line 1: skipped, the line has syntax errors\nline 1: skipped, the line has syntax errors\n" +
+				"2 of 2 statements failed, 2 skipped\n",
+			errors: "line 1: error: Expected 'IDENT'. Got ASIGN\nline 1: error: Not prefixFn found for: =\n" +
+				"line 2: error: Cannot resolve identifier: y\n  en main (línea 2)\n",
+		},
+		{
+			// the statements after the exit do not run, so they are not counted
+			input:  "1\nsalir(4)\n2\n3\n",
+			status: 4,
+			output: "line 1: 1\nline 2: exit with status 4\n0 of 2 statements failed\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		var output, errors bytes.Buffer
+		builder := NewReplBuilder().EachStatement().WithStdin(io.NopCloser(strings.NewReader(tc.input)))
+
+		status := newTestRepl(builder, &output, &errors).Run()
+		if status != tc.status {
+			t.Errorf("%q: expected status %d. Got %d", tc.input, tc.status, status)
+		}
+		if output.String() != tc.output {
+			t.Errorf("%q: expected the output %q. Got %q", tc.input, tc.output, output.String())
+		}
+		if errors.String() != tc.errors {
+			t.Errorf("%q: expected the errors %q. Got %q", tc.input, tc.errors, errors.String())
+		}
+	}
+}
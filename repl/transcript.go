@@ -0,0 +1,145 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// One input of a session with everything it printed. Transcripts are written as JSON
+// lines, one entry per input, so they can be replayed.
+type TranscriptEntry struct {
+	Time   time.Time `json:"time"`
+	Input  string    `json:"input"`
+	Output string    `json:"output"`
+	Errors string    `json:"errors"`
+
+	// the session was interactive, which prints the results with their names
+	Interactive bool `json:"interactive"`
+}
+
+type transcript struct {
+	encoder     *json.Encoder
+	output      *capture
+	errors      *capture
+	interactive bool
+}
+
+// Captures the output and errors of the repl to record them on the given file
+func newTranscript(file io.Writer, r *Repl) *transcript {
+	t := &transcript{
+		encoder:     json.NewEncoder(file),
+		output:      &capture{WriteCloser: r.outFile},
+		errors:      &capture{WriteCloser: r.errFile},
+		interactive: r.interactive,
+	}
+
+	r.outFile = t.output
+	r.errFile = t.errors
+
+	return t
+}
+
+// starts the recording of a new input
+func (t *transcript) begin() {
+	if t == nil {
+		return
+	}
+
+	t.output.buffer.Reset()
+	t.errors.buffer.Reset()
+}
+
+func (t *transcript) record(input string) {
+	if t == nil {
+		return
+	}
+
+	t.encoder.Encode(TranscriptEntry{
+		Time:   time.Now(),
+		Input:  input,
+		Output: t.output.buffer.String(),
+		Errors: t.errors.buffer.String(),
+
+		Interactive: t.interactive,
+	})
+}
+
+// writer which keeps a copy of everything written to it
+type capture struct {
+	io.WriteCloser
+	buffer bytes.Buffer
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	c.buffer.Write(p)
+	return c.WriteCloser.Write(p)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// Replays a transcript on a new session built from the given builder, on the mode of
+// the recorded one (interactive or not), reporting every input whose output or errors
+// differ from the recorded ones. Returns the number of inputs with differences.
+func Replay(file io.Reader, report io.Writer, builder ReplBuilder) (int, error) {
+	var output, errors bytes.Buffer
+	var r *Repl
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16*1024*1024)
+
+	differences := 0
+	for line := 1; scanner.Scan(); line++ {
+		var entry TranscriptEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return differences, fmt.Errorf("Invalid transcript entry on line %d: %s", line, err)
+		}
+
+		if r == nil {
+			builder = builder.WithStdout(nopWriteCloser{&output}).WithStderr(nopWriteCloser{&errors})
+			if entry.Interactive {
+				builder = builder.Interactive()
+			}
+			r = builder.Build()
+		}
+
+		output.Reset()
+		errors.Reset()
+		r.submit(entry.Input)
+
+		if output.String() == entry.Output && errors.String() == entry.Errors {
+			continue
+		}
+
+		differences++
+		fmt.Fprintf(report, "Input %d differs:\n%s\n", line, indent(entry.Input))
+		if output.String() != entry.Output {
+			fmt.Fprintf(report, "  expected output:\n%s\n  got:\n%s\n", indent(entry.Output), indent(output.String()))
+		}
+		if errors.String() != entry.Errors {
+			fmt.Fprintf(report, "  expected errors:\n%s\n  got:\n%s\n", indent(entry.Errors), indent(errors.String()))
+		}
+	}
+
+	return differences, scanner.Err()
+}
+
+func indent(text string) string {
+	var out bytes.Buffer
+	for _, line := range bytes.Split(bytes.TrimRight([]byte(text), "\n"), []byte("\n")) {
+		out.WriteString("    ")
+		out.Write(line)
+		out.WriteString("\n")
+	}
+
+	return string(bytes.TrimRight(out.Bytes(), "\n"))
+}
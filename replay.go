@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sl2.0/repl"
+	"github.com/sl2.0/tokens"
+)
+
+// The "replay" subcommand executes a transcript recorded with -transcript, reporting
+// every input whose output differs from the recorded one.
+// Usage: replay [-keywords es] [-max-time 40000] file
+func replay(args []string) int {
+	flags := newFlagSet("replay")
+	keywords := flags.String("keywords", tokens.Spanish.Name,
+		"Keyword set: "+strings.Join(tokens.KeywordSetNames(), ", "))
+	maxTime := flags.Int64("max-time", 40000, "Max time for execution")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 1
+	}
+
+	keywordSet, ok := tokens.LookupKeywordSet(*keywords)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Invalid keyword set: "+*keywords)
+		return 1
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening transcript file: "+err.Error())
+		return 1
+	}
+	defer f.Close()
+
+	builder := repl.NewReplBuilder().
+		WithKeywords(keywordSet).
+		WithTimeout(*maxTime)
+
+	differences, err := repl.Replay(f, os.Stdout, builder)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	if differences != 0 {
+		fmt.Printf("%d inputs differ from the transcript\n", differences)
+		return 1
+	}
+
+	fmt.Println("The transcript was reproduced without differences")
+	return 0
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sl2.0/coverage"
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/parser"
+	"github.com/sl2.0/profiler"
+	"github.com/sl2.0/repl"
+	"github.com/sl2.0/tokens"
+)
+
+// The "run" subcommand executes a program. The arguments after the file (optionally
+// separated by "--") are passed to the program.
+// Usage: run [-keywords es] [-max-time 40000] [-each] [-profile file] [-coverage prefix] [-o file] [-err file] [-append] [file|-] [-- args...]
+func run(args []string) int {
+	flags := newFlagSet("run")
+	keywords := addKeywordsFlag(flags)
+	maxTime := flags.Int64("max-time", 40000, "Max time for execution")
+	allowEnv := addAllowEnvFlag(flags)
+	limits := evaluator.Limits{}
+	addLimitFlags(flags, &limits)
+	each := flags.Bool("each", false, "Evaluate every statement of the input independently, reporting each result. "+
+		"The exit status is 3 if any statement fails")
+	profile := flags.String("profile", "", "File to write the profile of the functions of the program, "+
+		"as folded stacks for flame graph tools. A summary is printed to the standard error")
+	coverage := flags.String("coverage", "", "Prefix of the coverage reports of the program: "+
+		"<prefix>.lcov (LCOV format) and <prefix>.txt (annotated source)")
+	outputs := addOutputFlags(flags)
+	flags.Parse(args)
+
+	keywordSet, ok := lookupKeywords(*keywords)
+	if !ok {
+		return exitUsage
+	}
+
+	if *profile != "" && *coverage != "" {
+		fmt.Fprintln(os.Stderr, "The -profile and -coverage flags cannot be used together")
+		return exitUsage
+	}
+
+	path, scriptArgs := flags.Arg(0), []string{}
+	if flags.NArg() > 1 {
+		scriptArgs = flags.Args()[1:]
+	}
+	if len(scriptArgs) > 0 && scriptArgs[0] == "--" {
+		scriptArgs = scriptArgs[1:]
+	}
+
+	builder := repl.NewReplBuilder().
+		WithMode(repl.EVAL).
+		WithKeywords(keywordSet).
+		WithTimeout(*maxTime).
+		WithAllowedEnv(allowedEnv(*allowEnv)).
+		WithLimits(limits).
+		WithArgs(scriptArgs)
+
+	if *each {
+		builder = builder.EachStatement()
+	}
+
+	if path != "" && path != "-" {
+		builder = builder.WithFile(path)
+	}
+
+	if *coverage != "" {
+		return runWithCoverage(builder, path, *coverage, keywordSet, outputs)
+	}
+
+	if *profile == "" {
+		return runFile(builder, path, outputs)
+	}
+
+	prof := profiler.NewProfiler()
+	status := runFile(builder.WithTracer(prof), path, outputs)
+
+	if err := writeProfile(prof, *profile); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing the profile: "+err.Error())
+		return repl.EXIT_FAILURE
+	}
+
+	return status
+}
+
+// Runs the program recording its coverage, which is written to <prefix>.lcov and
+// <prefix>.txt. The program is parsed once more to know every statement, so the
+// input is read before running it.
+func runWithCoverage(builder repl.ReplBuilder, path, prefix string, keywords *tokens.KeywordSet, outputs *outputFlags) int {
+	src, err := readInput(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening input file: "+err.Error())
+		return repl.EXIT_FAILURE
+	}
+
+	program := parser.NewParserFromLexer(lexer.NewLexerWithKeywords(src, keywords)).ParseProgram()
+	cov := coverage.NewCoverage(program)
+
+	in := io.NopCloser(strings.NewReader(src))
+	status := runInput(builder.WithTracer(cov), in, outputs)
+
+	if path == "" || path == "-" {
+		path = "stdin"
+	}
+
+	if err := writeReport(prefix+".lcov", func(w io.Writer) error { return cov.WriteLCOV(w, path) }); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing the coverage: "+err.Error())
+		return repl.EXIT_FAILURE
+	}
+	if err := writeReport(prefix+".txt", func(w io.Writer) error { return cov.WriteReport(w, src) }); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing the coverage: "+err.Error())
+		return repl.EXIT_FAILURE
+	}
+
+	return status
+}
+
+// Creates the file and writes a report on it
+func writeReport(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Writes the folded stacks of the profile to the file, and its summary to the standard
+// error
+func writeProfile(prof *profiler.Profiler, path string) error {
+	if err := writeReport(path, prof.WriteFolded); err != nil {
+		return err
+	}
+
+	return prof.WriteSummary(os.Stderr)
+}
+
+// The "tokens" subcommand prints the tokens of a program.
+// Usage: tokens [-format text] [-keywords es] [-o file] [-err file] [-append] [file|-]
+func lexe(args []string) int {
+	flags := newFlagSet("tokens")
+	format := addFormatFlag(flags)
+	keywords := addKeywordsFlag(flags)
+	outputs := addOutputFlags(flags)
+	flags.Parse(args)
+
+	return printProgram(repl.NewReplBuilder().WithMode(repl.LEXER), flags.Arg(0), *format, *keywords, outputs)
+}
+
+// The "ast" subcommand prints the AST of a program.
+// Usage: ast [-format text] [-keywords es] [-o file] [-err file] [-append] [file|-]
+func parse(args []string) int {
+	flags := newFlagSet("ast")
+	format := addFormatFlag(flags)
+	keywords := addKeywordsFlag(flags)
+	outputs := addOutputFlags(flags)
+	flags.Parse(args)
+
+	return printProgram(repl.NewReplBuilder().WithMode(repl.PARSER), flags.Arg(0), *format, *keywords, outputs)
+}
+
+// Runs the tokens and ast subcommands, which only differ on the mode of the repl
+func printProgram(builder repl.ReplBuilder, path, format, keywords string, outputs *outputFlags) int {
+	keywordSet, ok := lookupKeywords(keywords)
+	if !ok {
+		return exitUsage
+	}
+	builder = builder.WithKeywords(keywordSet)
+
+	switch format {
+	case "text":
+		builder = builder.WithFormat(repl.TEXT)
+	case "json":
+		builder = builder.WithFormat(repl.JSON)
+	default:
+		fmt.Fprintln(os.Stderr, "Invalid format: "+format)
+		return exitUsage
+	}
+
+	return runFile(builder, path, outputs)
+}
+
+// Runs the repl over the whole content of a file ("-" or an empty path is the standard
+// input). Returns the exit status of the repl.
+func runFile(builder repl.ReplBuilder, path string, outputs *outputFlags) int {
+	in, err := openInput(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening input file: "+err.Error())
+		return repl.EXIT_FAILURE
+	}
+
+	return runInput(builder, in, outputs)
+}
+
+// Runs the repl over the whole input, which is closed at the end
+func runInput(builder repl.ReplBuilder, in io.ReadCloser, outputs *outputFlags) int {
+	defer in.Close()
+
+	builder, err := outputs.apply(builder.WithStdin(in))
+	defer outputs.close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return repl.EXIT_FAILURE
+	}
+
+	return builder.Build().Run()
+}
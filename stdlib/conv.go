@@ -0,0 +1,107 @@
+package stdlib
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sl2.0/objects"
+)
+
+// "conv": conversions between integers and strings
+func newConv() map[string]function {
+	return map[string]function{
+		"leer_entero": convParse,
+		"formatear":   convFormat,
+	}
+}
+
+// leer_entero(cadena, base) parses an integer written on the base (10 when omitted),
+// from 2 to 36. With the base 0, it is selected by the prefix of the number: "0b" for
+// binary, "0o" or "0" for octal and "0x" for hexadecimal.
+func convParse(args ...objects.Object) objects.Object {
+	const name = "conv.leer_entero"
+	if err := checkCount(name, args, 1, 2); err != nil {
+		return err
+	}
+	str, err := stringArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+
+	base := int64(10)
+	if len(args) == 2 {
+		if base, err = integerArg(name, args, 1); err != nil {
+			return err
+		}
+	}
+
+	if base != 0 && (base < 2 || base > 36) {
+		return objects.NewError("%s: the base must be 0 or from 2 to 36. Got %d", name, base)
+	}
+
+	value, parseErr := strconv.ParseInt(strings.TrimSpace(str), int(base), 64)
+	if parseErr != nil {
+		if parseErr.(*strconv.NumError).Err == strconv.ErrRange {
+			return objects.NewError("%s: '%s' overflows an integer", name, str)
+		}
+		return objects.NewError("%s: '%s' is not an integer on base %d", name, str, base)
+	}
+
+	return &objects.Integer{Value: value}
+}
+
+// formatear(entero, base, ancho, relleno) writes the integer on the base (10 when
+// omitted), from 2 to 36. The result is padded on the left with the fill character
+// (a space when omitted) up to the width. Zeros are placed after the sign.
+func convFormat(args ...objects.Object) objects.Object {
+	const name = "conv.formatear"
+	if err := checkCount(name, args, 1, 4); err != nil {
+		return err
+	}
+	value, err := integerArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+
+	base, width, fill := int64(10), int64(0), " "
+	if len(args) > 1 {
+		if base, err = integerArg(name, args, 1); err != nil {
+			return err
+		}
+	}
+	if len(args) > 2 {
+		if width, err = integerArg(name, args, 2); err != nil {
+			return err
+		}
+	}
+	if len(args) > 3 {
+		if fill, err = stringArg(name, args, 3); err != nil {
+			return err
+		}
+	}
+
+	if base < 2 || base > 36 {
+		return objects.NewError("%s: the base must be from 2 to 36. Got %d", name, base)
+	}
+	if width < 0 || width > maxRepeatLength {
+		return objects.NewError("%s: the width must be from 0 to %d. Got %d", name, maxRepeatLength, width)
+	}
+	if utf8.RuneCountInString(fill) != 1 {
+		return objects.NewError("%s: the fill must be a single character. Got '%s'", name, fill)
+	}
+
+	digits := strconv.FormatInt(value, int(base))
+
+	sign := ""
+	if fill == "0" && strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	padding := int(width) - len(sign) - len(digits)
+	if padding < 0 {
+		padding = 0
+	}
+
+	return &objects.String{Value: sign + strings.Repeat(fill, padding) + digits}
+}
@@ -0,0 +1,240 @@
+package stdlib
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/sl2.0/objects"
+)
+
+// "mate": integer arithmetic and random numbers
+func newMate() map[string]function {
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	return map[string]function{
+		"abs":      mateAbs,
+		"min":      mateMin,
+		"max":      mateMax,
+		"potencia": matePow,
+		"raiz":     mateSqrt,
+		"mcd":      mateGCD,
+
+		// aleatorio(min, max) returns a random integer between min and max (both
+		// included)
+		"aleatorio": func(args ...objects.Object) objects.Object {
+			const name = "mate.aleatorio"
+			if err := checkCount(name, args, 2, 2); err != nil {
+				return err
+			}
+			min, err := integerArg(name, args, 0)
+			if err != nil {
+				return err
+			}
+			max, err := integerArg(name, args, 1)
+			if err != nil {
+				return err
+			}
+
+			if min > max {
+				return objects.NewError("%s: the min %d is greater than the max %d", name, min, max)
+			}
+
+			return &objects.Integer{Value: randomBetween(random, min, max)}
+		},
+
+		// semilla(n) restarts the random numbers from the seed, so they are repeated
+		// on every execution
+		"semilla": func(args ...objects.Object) objects.Object {
+			if err := checkCount("mate.semilla", args, 1, 1); err != nil {
+				return err
+			}
+			seed, err := integerArg("mate.semilla", args, 0)
+			if err != nil {
+				return err
+			}
+
+			random.Seed(seed)
+
+			return nil
+		},
+	}
+}
+
+// abs(n) returns the absolute value of n
+func mateAbs(args ...objects.Object) objects.Object {
+	if err := checkCount("mate.abs", args, 1, 1); err != nil {
+		return err
+	}
+	n, err := integerArg("mate.abs", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if n == math.MinInt64 {
+		return objects.NewError("mate.abs: the result overflows an integer")
+	}
+	if n < 0 {
+		n = -n
+	}
+
+	return &objects.Integer{Value: n}
+}
+
+// min(a, b, ...) returns the smallest of the integers
+func mateMin(args ...objects.Object) objects.Object {
+	return extreme("mate.min", args, func(a, b int64) bool { return a < b })
+}
+
+// max(a, b, ...) returns the greatest of the integers
+func mateMax(args ...objects.Object) objects.Object {
+	return extreme("mate.max", args, func(a, b int64) bool { return a > b })
+}
+
+// Returns the argument which is better than every other one
+func extreme(name string, args []objects.Object, better func(a, b int64) bool) objects.Object {
+	if err := checkCount(name, args, 1, maxArgs); err != nil {
+		return err
+	}
+
+	result, err := integerArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+	for n := 1; n < len(args); n++ {
+		value, err := integerArg(name, args, n)
+		if err != nil {
+			return err
+		}
+		if better(value, result) {
+			result = value
+		}
+	}
+
+	return &objects.Integer{Value: result}
+}
+
+// potencia(base, exponente) returns base raised to a non negative exponent
+func matePow(args ...objects.Object) objects.Object {
+	const name = "mate.potencia"
+	if err := checkCount(name, args, 2, 2); err != nil {
+		return err
+	}
+	base, err := integerArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+	exp, err := integerArg(name, args, 1)
+	if err != nil {
+		return err
+	}
+
+	if exp < 0 {
+		return objects.NewError("%s: the exponent cannot be negative. Got %d", name, exp)
+	}
+
+	// exponentiation by squaring. The base is only squared when it is used again, so
+	// its overflows are overflows of the result.
+	result, ok := int64(1), true
+	for exp > 0 && ok {
+		if exp&1 == 1 {
+			result, ok = multiply(result, base)
+		}
+		exp >>= 1
+		if exp > 0 && ok {
+			base, ok = multiply(base, base)
+		}
+	}
+
+	if !ok {
+		return objects.NewError("%s: the result overflows an integer", name)
+	}
+
+	return &objects.Integer{Value: result}
+}
+
+// Returns the product, or false if it overflows
+func multiply(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+
+	return product, true
+}
+
+// raiz(n) returns the integer square root of n (rounded down)
+func mateSqrt(args ...objects.Object) objects.Object {
+	if err := checkCount("mate.raiz", args, 1, 1); err != nil {
+		return err
+	}
+	n, err := integerArg("mate.raiz", args, 0)
+	if err != nil {
+		return err
+	}
+
+	if n < 0 {
+		return objects.NewError("mate.raiz: cannot take the root of a negative number. Got %d", n)
+	}
+
+	// the float root can be off by one for big numbers. The squares are compared with
+	// divisions, so they cannot overflow.
+	root := int64(math.Sqrt(float64(n)))
+	for root > 0 && root > n/root {
+		root--
+	}
+	for root+1 <= n/(root+1) {
+		root++
+	}
+
+	return &objects.Integer{Value: root}
+}
+
+// mcd(a, b) returns the greatest common divisor of the integers (0 for mcd(0, 0))
+func mateGCD(args ...objects.Object) objects.Object {
+	if err := checkCount("mate.mcd", args, 2, 2); err != nil {
+		return err
+	}
+	a, err := integerArg("mate.mcd", args, 0)
+	if err != nil {
+		return err
+	}
+	b, err := integerArg("mate.mcd", args, 1)
+	if err != nil {
+		return err
+	}
+
+	// computed on unsigned values, so the min integer has an absolute value
+	x, y := absUint(a), absUint(b)
+	for y != 0 {
+		x, y = y, x%y
+	}
+
+	if x > math.MaxInt64 {
+		return objects.NewError("mate.mcd: the result overflows an integer")
+	}
+
+	return &objects.Integer{Value: int64(x)}
+}
+
+func absUint(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+
+	return uint64(n)
+}
+
+// Returns a random integer between min and max (both included)
+func randomBetween(random *rand.Rand, min, max int64) int64 {
+	span := uint64(max-min) + 1
+	if span == 0 { // every integer
+		return int64(random.Uint64())
+	}
+
+	return min + int64(random.Uint64()%span)
+}
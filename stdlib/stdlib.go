@@ -0,0 +1,110 @@
+package stdlib
+
+/*
+The standard library is a set of modules implemented in Go, which are imported by name
+instead of by path:
+
+	importar "mate" como mate
+	imprimir(mate.max(3, 7))
+
+Every import of a module on an evaluation returns the same instance, so the state of
+a module (like the seed of "mate.aleatorio") is shared by the whole program.
+*/
+
+import (
+	"sort"
+
+	"github.com/sl2.0/objects"
+)
+
+type function func(args ...objects.Object) objects.Object
+
+// Constructors of the modules, by name. Every call returns the functions of a new
+// instance of the module.
+var modules = map[string]func() map[string]function{
+	"mate":  newMate,
+	"texto": newTexto,
+	"conv":  newConv,
+}
+
+// Returns a new instance of the module with the given name
+func Load(name string) (*objects.Module, bool) {
+	constructor, ok := modules[name]
+	if !ok {
+		return nil, false
+	}
+
+	module := &objects.Module{Name: name, Env: objects.NewStorage()}
+	for fname, fn := range constructor() {
+		module.Env.Set(fname, &objects.BuiltinFunction{Name: name + "." + fname, Fn: fn})
+	}
+
+	return module, true
+}
+
+// Reports if the name is a module of the standard library
+func IsModule(name string) bool {
+	_, ok := modules[name]
+	return ok
+}
+
+// Returns the sorted names of the modules
+func Names() []string {
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// -- Arguments --
+
+func checkCount(name string, args []objects.Object, min, max int) objects.Object {
+	if len(args) >= min && len(args) <= max {
+		return nil
+	}
+
+	switch {
+	case min == max && min == 1:
+		return objects.NewError("%s: expected 1 argument. Got %d", name, len(args))
+	case min == max:
+		return objects.NewError("%s: expected %d arguments. Got %d", name, min, len(args))
+	case max == maxArgs:
+		return objects.NewError("%s: expected at least %d arguments. Got %d", name, min, len(args))
+	}
+
+	return objects.NewError("%s: expected from %d to %d arguments. Got %d", name, min, max, len(args))
+}
+
+// max for functions with a variable number of arguments
+const maxArgs = int(^uint(0) >> 1)
+
+func integerArg(name string, args []objects.Object, n int) (int64, objects.Object) {
+	value, ok := args[n].(*objects.Integer)
+	if !ok {
+		return 0, objects.NewError("%s: expected an integer as argument %d. Got %s", name, n+1, typeName(args[n]))
+	}
+
+	return value.Value, nil
+}
+
+func stringArg(name string, args []objects.Object, n int) (string, objects.Object) {
+	value, ok := args[n].(*objects.String)
+	if !ok {
+		return "", objects.NewError("%s: expected a string as argument %d. Got %s", name, n+1, typeName(args[n]))
+	}
+
+	return value.Value, nil
+}
+
+// type of an argument for error messages. Arguments can be nil (functions without
+// returned values).
+func typeName(obj objects.Object) objects.ObjectType {
+	if obj == nil {
+		return objects.NULL_OBJ
+	}
+
+	return obj.Type()
+}
@@ -0,0 +1,156 @@
+package stdlib
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sl2.0/objects"
+)
+
+type testCase struct {
+	function string
+	args     []objects.Object
+	expected string // Inspect() of the result, or the message of the error
+}
+
+func integer(value int64) objects.Object {
+	return &objects.Integer{Value: value}
+}
+
+func str(value string) objects.Object {
+	return &objects.String{Value: value}
+}
+
+func runCases(t *testing.T, module string, testCases []testCase) {
+	t.Helper()
+
+	m, ok := Load(module)
+	if !ok {
+		t.Fatalf("Module '%s' not found", module)
+	}
+
+	for _, tc := range testCases {
+		fn, ok := m.Env.Get(tc.function)
+		if !ok {
+			t.Errorf("Function '%s.%s' not found", module, tc.function)
+			continue
+		}
+
+		result := fn.(*objects.BuiltinFunction).Fn(tc.args...)
+		if result == nil {
+			t.Errorf("%s.%s%v: expected %q. Got no value", module, tc.function, tc.args, tc.expected)
+			continue
+		}
+		if result.Inspect() != tc.expected {
+			t.Errorf("%s.%s%v: expected %q. Got %q", module, tc.function, tc.args, tc.expected, result.Inspect())
+		}
+	}
+}
+
+func TestMate(t *testing.T) {
+	runCases(t, "mate", []testCase{
+		{"abs", []objects.Object{integer(-5)}, "5"},
+		{"abs", []objects.Object{integer(math.MinInt64)}, "mate.abs: the result overflows an integer"},
+		{"abs", []objects.Object{str("5")}, "mate.abs: expected an integer as argument 1. Got STRING"},
+		{"min", []objects.Object{integer(4), integer(-2), integer(8)}, "-2"},
+		{"max", []objects.Object{integer(4), integer(-2), integer(8)}, "8"},
+		{"max", []objects.Object{}, "mate.max: expected at least 1 arguments. Got 0"},
+		{"potencia", []objects.Object{integer(2), integer(10)}, "1024"},
+		{"potencia", []objects.Object{integer(-3), integer(3)}, "-27"},
+		{"potencia", []objects.Object{integer(7), integer(0)}, "1"},
+		{"potencia", []objects.Object{integer(2), integer(62)}, "4611686018427387904"},
+		{"potencia", []objects.Object{integer(2), integer(63)}, "mate.potencia: the result overflows an integer"},
+		{"potencia", []objects.Object{integer(-2), integer(63)}, "-9223372036854775808"},
+		{"potencia", []objects.Object{integer(2), integer(-1)}, "mate.potencia: the exponent cannot be negative. Got -1"},
+		{"raiz", []objects.Object{integer(17)}, "4"},
+		{"raiz", []objects.Object{integer(0)}, "0"},
+		{"raiz", []objects.Object{integer(math.MaxInt64)}, "3037000499"},
+		{"raiz", []objects.Object{integer(-1)}, "mate.raiz: cannot take the root of a negative number. Got -1"},
+		{"mcd", []objects.Object{integer(12), integer(-18)}, "6"},
+		{"mcd", []objects.Object{integer(0), integer(0)}, "0"},
+		{"aleatorio", []objects.Object{integer(5), integer(5)}, "5"},
+		{"aleatorio", []objects.Object{integer(2), integer(1)}, "mate.aleatorio: the min 2 is greater than the max 1"},
+	})
+}
+
+func TestRandomSeed(t *testing.T) {
+	m, _ := Load("mate")
+	seed, _ := m.Env.Get("semilla")
+	random, _ := m.Env.Get("aleatorio")
+
+	draw := func() []int64 {
+		seed.(*objects.BuiltinFunction).Fn(integer(42))
+
+		values := []int64{}
+		for n := 0; n < 20; n++ {
+			value := random.(*objects.BuiltinFunction).Fn(integer(-3), integer(3)).(*objects.Integer).Value
+			if value < -3 || value > 3 {
+				t.Fatalf("Expected a value between -3 and 3. Got %d", value)
+			}
+			values = append(values, value)
+		}
+		return values
+	}
+
+	first, second := draw(), draw()
+	for n := range first {
+		if first[n] != second[n] {
+			t.Fatalf("Expected the same values with the same seed. Got %v and %v", first, second)
+		}
+	}
+}
+
+func TestTexto(t *testing.T) {
+	runCases(t, "texto", []testCase{
+		{"reemplazar", []objects.Object{str("a-b-c"), str("-"), str("+")}, "a+b+c"},
+		{"mayusculas", []objects.Object{str("año")}, "AÑO"},
+		{"minusculas", []objects.Object{str("AÑO")}, "año"},
+		{"recortar", []objects.Object{str(" \thola \n")}, "hola"},
+		{"contiene", []objects.Object{str("hola"), str("ol")}, "true"},
+		{"contiene", []objects.Object{str("hola"), str("x")}, "false"},
+		{"indice", []objects.Object{str("añob"), str("b")}, "3"},
+		{"indice", []objects.Object{str("hola"), str("x")}, "-1"},
+		{"indice", []objects.Object{str("hola")}, "texto.indice: expected 2 arguments. Got 1"},
+		{"replicar", []objects.Object{str("ab"), integer(3)}, "ababab"},
+		{"replicar", []objects.Object{str("ab"), integer(0)}, ""},
+		{"replicar", []objects.Object{str("ab"), integer(-1)}, "texto.replicar: the count cannot be negative. Got -1"},
+		{"replicar", []objects.Object{str("ab"), integer(math.MaxInt64)}, "texto.replicar: the result would have more than 16777216 bytes"},
+		{"subcadena", []objects.Object{str("España"), integer(2), integer(5)}, "pañ"},
+		{"subcadena", []objects.Object{str("España"), integer(3)}, "aña"},
+		{"subcadena", []objects.Object{str("hola"), integer(3), integer(2)}, "texto.subcadena: range 3 to 2 out of the 4 characters of the string"},
+		{"subcadena", []objects.Object{integer(1), integer(0)}, "texto.subcadena: expected a string as argument 1. Got INTEGER"},
+	})
+}
+
+func TestConv(t *testing.T) {
+	runCases(t, "conv", []testCase{
+		{"leer_entero", []objects.Object{str("-42")}, "-42"},
+		{"leer_entero", []objects.Object{str("ff"), integer(16)}, "255"},
+		{"leer_entero", []objects.Object{str("zz"), integer(36)}, "1295"},
+		{"leer_entero", []objects.Object{str("0x1F"), integer(0)}, "31"},
+		{"leer_entero", []objects.Object{str("12"), integer(2)}, "conv.leer_entero: '12' is not an integer on base 2"},
+		{"leer_entero", []objects.Object{str("99999999999999999999")}, "conv.leer_entero: '99999999999999999999' overflows an integer"},
+		{"leer_entero", []objects.Object{str("1"), integer(37)}, "conv.leer_entero: the base must be 0 or from 2 to 36. Got 37"},
+		{"formatear", []objects.Object{integer(255)}, "255"},
+		{"formatear", []objects.Object{integer(255), integer(16)}, "ff"},
+		{"formatear", []objects.Object{integer(5), integer(2), integer(8), str("0")}, "00000101"},
+		{"formatear", []objects.Object{integer(-42), integer(10), integer(6)}, "   -42"},
+		{"formatear", []objects.Object{integer(-42), integer(10), integer(6), str("0")}, "-00042"},
+		{"formatear", []objects.Object{integer(42), integer(10), integer(5), str("·")}, "···42"},
+		{"formatear", []objects.Object{integer(12345), integer(10), integer(2)}, "12345"},
+		{"formatear", []objects.Object{integer(1), integer(1)}, "conv.formatear: the base must be from 2 to 36. Got 1"},
+		{"formatear", []objects.Object{integer(1), integer(10), integer(3), str("ab")}, "conv.formatear: the fill must be a single character. Got 'ab'"},
+	})
+}
+
+func TestLoad(t *testing.T) {
+	for _, name := range Names() {
+		if !IsModule(name) {
+			t.Errorf("Expected '%s' to be a module", name)
+		}
+	}
+
+	if _, ok := Load("nada"); ok || IsModule("nada") {
+		t.Errorf("Expected 'nada' to not be a module")
+	}
+}
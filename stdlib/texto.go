@@ -0,0 +1,166 @@
+package stdlib
+
+import (
+	"strings"
+
+	"github.com/sl2.0/objects"
+)
+
+// max length in bytes of the strings made by "texto.replicar"
+const maxRepeatLength = 1 << 24
+
+// "texto": operations on strings. Positions and lengths are counted in characters (not
+// bytes), like the ones of "longitud".
+func newTexto() map[string]function {
+	return map[string]function{
+		"reemplazar": textoReplace,
+		"mayusculas": textoUpper,
+		"minusculas": textoLower,
+		"recortar":   textoTrim,
+		"contiene":   textoContains,
+		"indice":     textoIndex,
+		"replicar":   textoRepeat,
+		"subcadena":  textoSubstring,
+	}
+}
+
+// reemplazar(cadena, viejo, nuevo) replaces every occurrence of viejo with nuevo
+func textoReplace(args ...objects.Object) objects.Object {
+	strs, err := stringArgs("texto.reemplazar", args, 3)
+	if err != nil {
+		return err
+	}
+
+	return &objects.String{Value: strings.ReplaceAll(strs[0], strs[1], strs[2])}
+}
+
+// mayusculas(cadena) converts the string to upper case
+func textoUpper(args ...objects.Object) objects.Object {
+	strs, err := stringArgs("texto.mayusculas", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return &objects.String{Value: strings.ToUpper(strs[0])}
+}
+
+// minusculas(cadena) converts the string to lower case
+func textoLower(args ...objects.Object) objects.Object {
+	strs, err := stringArgs("texto.minusculas", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return &objects.String{Value: strings.ToLower(strs[0])}
+}
+
+// recortar(cadena) removes the spaces (and other white space) around the string
+func textoTrim(args ...objects.Object) objects.Object {
+	strs, err := stringArgs("texto.recortar", args, 1)
+	if err != nil {
+		return err
+	}
+
+	return &objects.String{Value: strings.TrimSpace(strs[0])}
+}
+
+// contiene(cadena, parte) reports if the string contains the part
+func textoContains(args ...objects.Object) objects.Object {
+	strs, err := stringArgs("texto.contiene", args, 2)
+	if err != nil {
+		return err
+	}
+
+	return &objects.Boolean{Value: strings.Contains(strs[0], strs[1])}
+}
+
+// indice(cadena, parte) returns the position of the first occurrence of the part, or -1
+// if the string does not contain it
+func textoIndex(args ...objects.Object) objects.Object {
+	strs, err := stringArgs("texto.indice", args, 2)
+	if err != nil {
+		return err
+	}
+
+	index := strings.Index(strs[0], strs[1])
+	if index < 0 {
+		return &objects.Integer{Value: -1}
+	}
+
+	return &objects.Integer{Value: int64(len([]rune(strs[0][:index])))}
+}
+
+// replicar(cadena, n) returns the string repeated n times
+func textoRepeat(args ...objects.Object) objects.Object {
+	const name = "texto.replicar"
+	if err := checkCount(name, args, 2, 2); err != nil {
+		return err
+	}
+	str, err := stringArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+	times, err := integerArg(name, args, 1)
+	if err != nil {
+		return err
+	}
+
+	if times < 0 {
+		return objects.NewError("%s: the count cannot be negative. Got %d", name, times)
+	}
+	if len(str) > 0 && times > maxRepeatLength/int64(len(str)) {
+		return objects.NewError("%s: the result would have more than %d bytes", name, maxRepeatLength)
+	}
+
+	return &objects.String{Value: strings.Repeat(str, int(times))}
+}
+
+// subcadena(cadena, inicio, fin) returns the characters from inicio (included) to fin
+// (excluded). Without fin, the rest of the string is returned.
+func textoSubstring(args ...objects.Object) objects.Object {
+	const name = "texto.subcadena"
+	if err := checkCount(name, args, 2, 3); err != nil {
+		return err
+	}
+	str, err := stringArg(name, args, 0)
+	if err != nil {
+		return err
+	}
+	start, err := integerArg(name, args, 1)
+	if err != nil {
+		return err
+	}
+
+	runes := []rune(str)
+	end := int64(len(runes))
+	if len(args) == 3 {
+		if end, err = integerArg(name, args, 2); err != nil {
+			return err
+		}
+	}
+
+	if start < 0 || end > int64(len(runes)) || start > end {
+		return objects.NewError("%s: range %d to %d out of the %d characters of the string",
+			name, start, end, len(runes))
+	}
+
+	return &objects.String{Value: string(runes[start:end])}
+}
+
+// Checks that the function received exactly n strings, and returns them
+func stringArgs(name string, args []objects.Object, n int) ([]string, objects.Object) {
+	if err := checkCount(name, args, n, n); err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, 0, n)
+	for i := range args {
+		str, err := stringArg(name, args, i)
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, str)
+	}
+
+	return strs, nil
+}
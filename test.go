@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sl2.0/repl"
+	"github.com/sl2.0/testrunner"
+)
+
+// exit status when some test failed
+const exitTestsFailed = 1
+
+// The "test" subcommand runs the "prueba" blocks of the files ending with "_prueba.sl"
+// found on the given paths (the current directory by default), and reports the results.
+// Usage: test [-keywords es] [-format text] [-timeout 10000] [path]...
+func runTests(args []string) int {
+	flags := newFlagSet("test")
+	keywords := addKeywordsFlag(flags)
+	format := flags.String("format", "text", "Format of the report: text, tap, junit")
+	timeout := flags.Int64("timeout", 10000, "Max time for every test in milliseconds (0 for unlimited)")
+	flags.Parse(args)
+
+	keywordSet, ok := lookupKeywords(*keywords)
+	if !ok {
+		return exitUsage
+	}
+
+	var write func(*testrunner.Report) error
+	switch *format {
+	case "text":
+		write = func(r *testrunner.Report) error { return r.WriteText(os.Stdout) }
+	case "tap":
+		write = func(r *testrunner.Report) error { return r.WriteTAP(os.Stdout) }
+	case "junit":
+		write = func(r *testrunner.Report) error { return r.WriteJUnit(os.Stdout) }
+	default:
+		fmt.Fprintln(os.Stderr, "Invalid format: "+*format)
+		return exitUsage
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := testrunner.Discover(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error searching the tests: "+err.Error())
+		return repl.EXIT_FAILURE
+	}
+
+	runner := testrunner.NewRunner()
+	runner.Keywords = keywordSet
+	runner.Timeout = time.Duration(*timeout) * time.Millisecond
+
+	report := &testrunner.Report{}
+	for _, file := range files {
+		report.Files = append(report.Files, runner.RunFile(file))
+	}
+
+	if err := write(report); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing the report: "+err.Error())
+		return repl.EXIT_FAILURE
+	}
+
+	if !report.Passed() {
+		return exitTestsFailed
+	}
+
+	return repl.EXIT_OK
+}
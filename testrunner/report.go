@@ -0,0 +1,234 @@
+package testrunner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Results of a run of the tests of many files
+type Report struct {
+	Files []FileResult
+}
+
+// Counts the tests by status. Files which could not run count as an error.
+func (r *Report) Count() (passed, failed, errors int) {
+	for _, file := range r.Files {
+		if file.Error != "" {
+			errors++
+		}
+
+		for _, test := range file.Tests {
+			switch test.Status {
+			case PASS:
+				passed++
+			case FAIL:
+				failed++
+			default:
+				errors++
+			}
+		}
+	}
+
+	return passed, failed, errors
+}
+
+// Reports if every test passed
+func (r *Report) Passed() bool {
+	_, failed, errors := r.Count()
+	return failed == 0 && errors == 0
+}
+
+// Writes a line per test, with the reason of the failures and errors, followed by a
+// summary
+func (r *Report) WriteText(w io.Writer) error {
+	var out strings.Builder
+
+	for _, file := range r.Files {
+		if file.Error != "" {
+			fmt.Fprintf(&out, "ERROR %s\n", file.Path)
+			out.WriteString(indent(file.Error, "      "))
+			continue
+		}
+
+		for _, test := range file.Tests {
+			switch test.Status {
+			case PASS:
+				fmt.Fprintf(&out, "ok    %s: %s (%s)\n", file.Path, test.Name, test.Duration.Round(time.Microsecond))
+			default:
+				fmt.Fprintf(&out, "%-5s %s: %s (line %d)\n", strings.ToUpper(string(test.Status)), file.Path, test.Name, test.Line)
+				out.WriteString(indent(test.Message, "      "))
+				if test.Trace != "" {
+					out.WriteString(indent(test.Trace, "    "))
+				}
+			}
+		}
+	}
+
+	passed, failed, errors := r.Count()
+	fmt.Fprintf(&out, "\n%d passed, %d failed, %d errors\n", passed, failed, errors)
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// Writes the results on the Test Anything Protocol (version 13). The reasons of the
+// failures are written as YAML blocks.
+func (r *Report) WriteTAP(w io.Writer) error {
+	var out strings.Builder
+
+	total := 0
+	for _, file := range r.Files {
+		total += max(len(file.Tests), 1)
+	}
+
+	out.WriteString("TAP version 13\n")
+	fmt.Fprintf(&out, "1..%d\n", total)
+
+	n := 0
+	for _, file := range r.Files {
+		if file.Error != "" || len(file.Tests) == 0 {
+			n++
+			if file.Error == "" {
+				fmt.Fprintf(&out, "ok %d - %s # SKIP no tests\n", n, file.Path)
+				continue
+			}
+			fmt.Fprintf(&out, "not ok %d - %s\n", n, file.Path)
+			writeYAML(&out, file.Error, "error", 0)
+			continue
+		}
+
+		for _, test := range file.Tests {
+			n++
+			if test.Status == PASS {
+				fmt.Fprintf(&out, "ok %d - %s: %s\n", n, file.Path, test.Name)
+				continue
+			}
+
+			fmt.Fprintf(&out, "not ok %d - %s: %s\n", n, file.Path, test.Name)
+			writeYAML(&out, test.Message, string(test.Status), test.Line)
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func writeYAML(out *strings.Builder, message, severity string, line int) {
+	out.WriteString("  ---\n")
+	out.WriteString("  message: |\n")
+	out.WriteString(indent(message, "    "))
+	fmt.Fprintf(out, "  severity: %s\n", severity)
+	if line > 0 {
+		fmt.Fprintf(out, "  line: %d\n", line)
+	}
+	out.WriteString("  ...\n")
+}
+
+// Prefixes every line of the text, which always ends with a line break
+func indent(text, prefix string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	return prefix + strings.Join(lines, "\n"+prefix) + "\n"
+}
+
+// -- JUnit XML --
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Error    *junitIssue `xml:"error,omitempty"` // the file could not run
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string      `xml:"name,attr"`
+	Classname string      `xml:"classname,attr"`
+	Time      string      `xml:"time,attr"`
+	Failure   *junitIssue `xml:"failure,omitempty"`
+	Error     *junitIssue `xml:"error,omitempty"`
+	Output    string      `xml:"system-out,omitempty"`
+}
+
+type junitIssue struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Writes the results on the JUnit XML format read by the continuous integration
+// tools. Every file is a test suite.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suites := junitSuites{}
+
+	for _, file := range r.Files {
+		suite := junitSuite{Name: file.Path, Tests: len(file.Tests)}
+		if file.Error != "" {
+			suite.Errors++
+			suite.Error = &junitIssue{Message: firstLine(file.Error), Text: file.Error}
+		}
+
+		var total time.Duration
+		for _, test := range file.Tests {
+			total += test.Duration
+
+			testCase := junitCase{
+				Name:      test.Name,
+				Classname: file.Path,
+				Time:      seconds(test.Duration),
+				Output:    test.Output,
+			}
+
+			issue := &junitIssue{Message: test.Message, Text: fmt.Sprintf("line %d: %s\n%s", test.Line, test.Message, test.Trace)}
+			switch test.Status {
+			case FAIL:
+				suite.Failures++
+				testCase.Failure = issue
+			case ERROR:
+				suite.Errors++
+				testCase.Error = issue
+			}
+
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		suite.Time = seconds(total)
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
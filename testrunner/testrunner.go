@@ -0,0 +1,226 @@
+package testrunner
+
+/*
+Runner runs the tests of the programs, declared with "prueba" blocks:
+
+	func suma(a, b) {
+	    retorna a + b
+	}
+
+	prueba "suma de enteros" {
+	    afirmar_igual(suma(2, 3), 5)
+	}
+
+The rest of the file is evaluated once before its tests, which are run in order. Every
+test has its own scope enclosed by the one of the file, so the variables declared on a
+test are not visible to the others. The output of the tests is kept on their results,
+and the one of the rest of the file is discarded.
+
+A test fails when an assertion fails, and has an error when the evaluation fails in any
+other way (like runtime errors or calls to "salir").
+*/
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sl2.0/ast"
+	"github.com/sl2.0/evaluator"
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/objects"
+	"github.com/sl2.0/parser"
+	"github.com/sl2.0/tokens"
+)
+
+// suffix of the files with tests found by Discover
+const FileSuffix = "_prueba.sl"
+
+type Status string
+
+const (
+	PASS  Status = "ok"
+	FAIL  Status = "fail"  // an assertion failed
+	ERROR Status = "error" // the evaluation failed
+)
+
+// Result of a test
+type Result struct {
+	Name     string
+	Status   Status
+	Message  string // reason of the failure or error
+	Trace    string // stack trace of the failure or error
+	Line     int    // line of the test, or of the failure
+	Output   string // written by the test
+	Duration time.Duration
+}
+
+// Results of the tests of a file
+type FileResult struct {
+	Path  string
+	Error string // syntax errors, or the error of the code outside the tests
+	Tests []Result
+}
+
+type Runner struct {
+	Keywords *tokens.KeywordSet // set of the files without a pragma
+	Timeout  time.Duration      // max time of every test (0 for unlimited)
+}
+
+func NewRunner() *Runner {
+	return &Runner{Keywords: tokens.Spanish}
+}
+
+// Returns the files with tests on the paths, sorted. Directories are searched
+// recursively for files ending with FileSuffix, and files are always included.
+func Discover(paths []string) ([]string, error) {
+	return FindFiles(paths, func(file string) bool {
+		return strings.HasSuffix(file, FileSuffix)
+	})
+}
+
+// Returns the files on the paths accepted by match, sorted. Directories are searched
+// recursively, and the files given directly are always included.
+func FindFiles(paths []string, match func(file string) bool) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && match(file) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Runs the tests of the file
+func (r *Runner) RunFile(path string) FileResult {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return FileResult{Path: path, Error: err.Error(), Tests: []Result{}}
+	}
+
+	return r.RunSource(path, string(src))
+}
+
+// Runs the tests of a program. The path identifies the results, and resolves the
+// imports of the program.
+func (r *Runner) RunSource(path, src string) FileResult {
+	result := FileResult{Path: path, Tests: []Result{}}
+
+	p := parser.NewParserFromLexer(lexer.NewLexerWithKeywords(src, r.Keywords))
+	program := p.ParseProgram()
+	if p.HasErrors() {
+		result.Error = strings.Join(p.Errors(), "\n")
+		return result
+	}
+
+	// the tests are skipped by the evaluator, so only the rest of the file runs here
+	file := &file{path: path, modules: evaluator.NewModules(r.Keywords)}
+	env := objects.NewStorage()
+	var output bytes.Buffer
+	if setup := r.evaluate(file, program, env, &output); setup != nil {
+		result.Error = fmt.Sprintf("line %d: %s", setup.Line, setup.Message)
+		return result
+	}
+
+	for _, stmt := range program.Statements {
+		if test, ok := stmt.(*ast.TestStatement); ok {
+			result.Tests = append(result.Tests, r.runTest(file, test, env))
+		}
+	}
+
+	return result
+}
+
+func (r *Runner) runTest(file *file, test *ast.TestStatement, global *objects.Storage) Result {
+	line, _ := test.Position()
+	result := Result{Name: test.Name.Value, Status: PASS, Line: line}
+
+	env, err := objects.NewEnclosedStorage(global)
+	if err != nil {
+		result.Status, result.Message = ERROR, err.Error()
+		return result
+	}
+
+	var output bytes.Buffer
+	start := time.Now()
+	failure := r.evaluate(file, &ast.Program{Statements: test.Body.Statements}, env, &output)
+	result.Duration = time.Since(start)
+	result.Output = output.String()
+
+	if failure != nil {
+		result.Status = failure.Status
+		result.Message = failure.Message
+		result.Trace = failure.Trace
+		if failure.Line > 0 {
+			result.Line = failure.Line
+		}
+	}
+
+	return result
+}
+
+// File being tested. Its modules are imported once for every test.
+type file struct {
+	path    string
+	modules *evaluator.Modules
+}
+
+// Evaluates the program, returning the failure or error of the evaluation (nil if it
+// succeeds)
+func (r *Runner) evaluate(file *file, program *ast.Program, env *objects.Storage, output *bytes.Buffer) *Result {
+	ev := evaluator.NewFromProgram(program)
+	ev.SetOutput(output)
+	ev.SetPath(file.path)
+	ev.SetModules(file.modules)
+
+	if r.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), r.Timeout)
+		defer cancel()
+		ev.SetContext(ctx)
+	}
+
+	switch evaluated := ev.EvalProgram(env).(type) {
+	case *objects.ErrorObject:
+		failure := &Result{Status: ERROR, Message: evaluated.Inspect(), Trace: evaluated.StackTrace()}
+		if evaluated.Kind == objects.ASSERT_ERROR {
+			failure.Status = FAIL
+		}
+		if len(evaluated.Trace) > 0 {
+			failure.Line = evaluated.Trace[0].Line
+		}
+		return failure
+
+	case *objects.ExitObject:
+		return &Result{Status: ERROR, Message: fmt.Sprintf("The program called salir(%d)", evaluated.Code)}
+	}
+
+	return nil
+}
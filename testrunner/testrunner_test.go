@@ -0,0 +1,142 @@
+package testrunner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const program = `func suma(a, b) {
+    retorna a + b
+}
+var base = 10
+
+prueba "pasa" {
+    var base = 1
+    afirmar_igual(suma(base, 1), 2)
+}
+
+prueba "aislada" {
+    imprimir(base)
+    afirmar(base == 10, "la variable de otra prueba es visible")
+}
+
+prueba "falla" {
+    afirmar_igual(suma(2, 3), 6)
+}
+
+prueba "error" {
+    desconocida
+}
+
+prueba "sale" {
+    salir(1)
+}
+`
+
+func TestRunSource(t *testing.T) {
+	result := NewRunner().RunSource("suma_prueba.sl", program)
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+
+	summary := []string{}
+	for _, test := range result.Tests {
+		summary = append(summary, string(test.Status)+" "+test.Name)
+	}
+	expected := []string{"ok pasa", "ok aislada", "fail falla", "error error", "error sale"}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Fatalf("expected %v. Got %v", expected, summary)
+	}
+
+	if output := result.Tests[1].Output; output != "10\n" {
+		t.Errorf("expected the output of the test. Got %q", output)
+	}
+
+	failed := result.Tests[2]
+	if failed.Message != "Expected 6. Got 5" || failed.Line != 17 {
+		t.Errorf("expected the failure on line 17. Got %+v", failed)
+	}
+
+	// syntax errors and errors outside the tests stop the file
+	if result := NewRunner().RunSource("a", "prueba {\n}\n"); result.Error == "" {
+		t.Errorf("expected a syntax error")
+	}
+	if result := NewRunner().RunSource("a", "1 / 0\nprueba \"x\" {\n}\n"); result.Error != "line 1: Division by zero" || len(result.Tests) != 0 {
+		t.Errorf("expected an error outside the tests. Got %+v", result)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	runner := NewRunner()
+	runner.Timeout = 10 * time.Millisecond
+
+	src := "func f(n) {\n    repetir 1000000 {\n        n + 1\n    }\n}\nprueba \"lenta\" {\n    f(1)\n}\n"
+	result := runner.RunSource("lenta", src)
+	if len(result.Tests) != 1 || result.Tests[0].Status != ERROR {
+		t.Errorf("expected an error by the timeout. Got %+v", result)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b_prueba.sl", "otro.sl", "sub/a_prueba.sl"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte("1"), 0o644)
+	}
+
+	files, err := Discover([]string{dir, filepath.Join(dir, "otro.sl")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(dir, "b_prueba.sl"),
+		filepath.Join(dir, "otro.sl"),
+		filepath.Join(dir, "sub/a_prueba.sl"),
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v. Got %v", expected, files)
+	}
+}
+
+func TestReports(t *testing.T) {
+	report := &Report{Files: []FileResult{
+		{Path: "a_prueba.sl", Tests: []Result{
+			{Name: "pasa", Status: PASS},
+			{Name: "falla", Status: FAIL, Message: "Expected 2. Got 1", Line: 3},
+		}},
+		{Path: "b_prueba.sl", Error: "Expected '{'. Got EOF"},
+	}}
+
+	if passed, failed, errors := report.Count(); passed != 1 || failed != 1 || errors != 1 || report.Passed() {
+		t.Errorf("expected 1 test of each status. Got %d, %d, %d", passed, failed, errors)
+	}
+
+	var out bytes.Buffer
+	report.WriteTAP(&out)
+	for _, line := range []string{"TAP version 13", "1..3", "ok 1 - a_prueba.sl: pasa", "not ok 2 - a_prueba.sl: falla", "  line: 3", "not ok 3 - b_prueba.sl"} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected the TAP line %q. Got:\n%s", line, out.String())
+		}
+	}
+
+	out.Reset()
+	report.WriteJUnit(&out)
+	for _, fragment := range []string{`<testsuites tests="2" failures="1" errors="1">`, `<failure message="Expected 2. Got 1">`, `<testsuite name="b_prueba.sl" tests="0" failures="0" errors="1"`} {
+		if !strings.Contains(out.String(), fragment) {
+			t.Errorf("expected the JUnit fragment %q. Got:\n%s", fragment, out.String())
+		}
+	}
+
+	out.Reset()
+	report.WriteText(&out)
+	if !strings.HasSuffix(out.String(), "1 passed, 1 failed, 1 errors\n") {
+		t.Errorf("expected the summary of the tests. Got:\n%s", out.String())
+	}
+}
@@ -3,8 +3,13 @@ package tokens
 type TokenType string
 
 type Token struct {
-	Type    TokenType
-	Literal string
+	Type    TokenType `json:"type"`
+	Literal string    `json:"literal"`
+
+	// position of the first character of the token. Columns are counted in
+	// characters (not bytes). Both start from 1.
+	Line   int `json:"line"`
+	Column int `json:"column"`
 }
 
 // token types
@@ -17,6 +22,13 @@ const (
 	ELSE     = "ELSE"
 	FOR      = "FOR"
 	RETURN   = "RETURN"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	THROW    = "THROW"
+	TEST     = "TEST"
+	IMPORT   = "IMPORT"
+	AS       = "AS"
 	DATATYPE = "DATATYPE" // a datatype declaration token
 
 	// primitive data types
@@ -25,11 +37,18 @@ const (
 	TRUE   = "TRUE"
 	FALSE  = "FALSE"
 
+	// interpolated strings ("head ${expr} middle ${expr} tail")
+	INTERP_START = "INTERP_START" // text before the first "${"
+	INTERP_MID   = "INTERP_MID"   // text between a "}" and the next "${"
+	INTERP_END   = "INTERP_END"   // text between the last "}" and the closing '"'
+
 	// especial characters
 	COLON     = "COLON"     // :
+	DOT       = "DOT"       // .
 	SEMICOLON = "SEMICOLON" // ;
 	EOF       = "EOF"
 	LINEBREAK = "LINEBREAK"
+	COMMENT   = "COMMENT" // only produced when the lexer keeps comments
 	ILLEGAL   = "ILLEGAL"
 
 	// operators
@@ -52,25 +71,119 @@ const (
 	GT    = "GT"    // >
 )
 
-var keywords = map[string]TokenType{
-	"func":    FUNCTION,
-	"var":     VAR,
-	"si":      IF,
-	"sino":    ELSE,
-	"repetir": FOR,
-	"retorna": RETURN,
+// A reserved word of the language and the token type it produces
+type Keyword struct {
+	Literal string
+	Type    TokenType
+}
+
+// A complete table of reserved words. Every set lists its keywords in the same order,
+// so the keyword at a given index has the same meaning in every set.
+type KeywordSet struct {
+	Name     string
+	Keywords []Keyword
+
+	types map[string]TokenType
+}
+
+func newKeywordSet(name string, keywords ...Keyword) *KeywordSet {
+	set := &KeywordSet{
+		Name:     name,
+		Keywords: keywords,
+		types:    make(map[string]TokenType),
+	}
+
+	for _, k := range keywords {
+		set.types[k.Literal] = k.Type
+	}
+
+	return set
+}
+
+var Spanish = newKeywordSet("es",
+	Keyword{"func", FUNCTION},
+	Keyword{"var", VAR},
+	Keyword{"si", IF},
+	Keyword{"sino", ELSE},
+	Keyword{"repetir", FOR},
+	Keyword{"retorna", RETURN},
+	Keyword{"intentar", TRY},
+	Keyword{"capturar", CATCH},
+	Keyword{"finalmente", FINALLY},
+	Keyword{"lanzar", THROW},
+	Keyword{"prueba", TEST},
+	Keyword{"importar", IMPORT},
+	Keyword{"como", AS},
+
+	// datatype keywords
+	Keyword{"entero", DATATYPE},
+	Keyword{"cadena", DATATYPE},
+	Keyword{"true", TRUE},
+	Keyword{"false", FALSE},
+)
+
+var English = newKeywordSet("en",
+	Keyword{"func", FUNCTION},
+	Keyword{"var", VAR},
+	Keyword{"if", IF},
+	Keyword{"else", ELSE},
+	Keyword{"repeat", FOR},
+	Keyword{"return", RETURN},
+	Keyword{"try", TRY},
+	Keyword{"catch", CATCH},
+	Keyword{"finally", FINALLY},
+	Keyword{"throw", THROW},
+	Keyword{"test", TEST},
+	Keyword{"import", IMPORT},
+	Keyword{"as", AS},
 
 	// datatype keywords
-	"entero": DATATYPE,
-	"cadena": DATATYPE,
-	"true":   TRUE,
-	"false":  FALSE,
+	Keyword{"int", DATATYPE},
+	Keyword{"string", DATATYPE},
+	Keyword{"true", TRUE},
+	Keyword{"false", FALSE},
+)
+
+// the default set of keywords
+var keywords = Spanish
+
+var keywordSets = map[string]*KeywordSet{
+	Spanish.Name: Spanish,
+	English.Name: English,
+}
+
+// Returns the keyword set with the given name ("es", "en")
+func LookupKeywordSet(name string) (*KeywordSet, bool) {
+	set, ok := keywordSets[name]
+	return set, ok
 }
 
+// Returns the names of every available keyword set
+func KeywordSetNames() []string {
+	return []string{Spanish.Name, English.Name}
+}
+
+// Resolves the token type of an identifier using the default keyword set
 func ResolveType(ident string) TokenType {
-	if tType, ok := keywords[ident]; ok {
+	return keywords.Resolve(ident)
+}
+
+func (k *KeywordSet) Resolve(ident string) TokenType {
+	if tType, ok := k.types[ident]; ok {
 		return tType
 	}
 
 	return IDENT
 }
+
+// Returns the keyword of the "to" set equivalent to the given word. Returns false if
+// the word is not a keyword of this set.
+func (k *KeywordSet) Translate(word string, to *KeywordSet) (string, bool) {
+	for i, keyword := range k.Keywords {
+		if keyword.Literal == word {
+			return to.Keywords[i].Literal, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sl2.0/lexer"
+	"github.com/sl2.0/tokens"
+)
+
+// The "translate" subcommand rewrites a program from one keyword set to another.
+// Usage: translate [-from es] [-to en] [file]
+func translate(args []string) int {
+	flags := newFlagSet("translate")
+	sets := strings.Join(tokens.KeywordSetNames(), ", ")
+	from := flags.String("from", tokens.Spanish.Name, "Keyword set of the input ("+sets+
+		"). A '// idioma: <name>' comment on the input takes precedence")
+	to := flags.String("to", tokens.English.Name, "Keyword set of the output ("+sets+")")
+	outputFile := flags.String("o", "", "File to output the translated program")
+	flags.Parse(args)
+
+	fromSet, ok := tokens.LookupKeywordSet(*from)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Invalid keyword set: "+*from)
+		return 1
+	}
+
+	toSet, ok := tokens.LookupKeywordSet(*to)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Invalid keyword set: "+*to)
+		return 1
+	}
+
+	var input io.Reader = os.Stdin
+	if flags.NArg() > 0 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error opening input file: "+err.Error())
+			return 1
+		}
+		defer f.Close()
+		input = f
+	}
+
+	source, err := io.ReadAll(input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading input: "+err.Error())
+		return 1
+	}
+
+	translated, err := lexer.Translate(string(source), fromSet, toSet)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	out := os.Stdout
+	if *outputFile != "" {
+		out, err = os.Create(*outputFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error opening output file: "+err.Error())
+			return 1
+		}
+		defer out.Close()
+	}
+
+	fmt.Fprint(out, translated)
+
+	return 0
+}
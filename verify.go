@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sl2.0/golden"
+	"github.com/sl2.0/repl"
+)
+
+// exit status when some program differs from its expectation files
+const exitVerifyFailed = 1
+
+// The "verify" subcommand executes the ".sl" programs found on the given paths (the
+// current directory by default), comparing their output and errors with the ".out" and
+// ".err" files next to them. Directories are searched for the programs with those files.
+// With -update the expectation files are rewritten instead.
+// Usage: verify [-keywords es] [-max-time 40000] [-update] [path]...
+func verify(args []string) int {
+	flags := newFlagSet("verify")
+	keywords := addKeywordsFlag(flags)
+	maxTime := flags.Int64("max-time", 40000, "Max time for every program in milliseconds")
+	update := flags.Bool("update", false, "Rewrite the expectation files with the current results")
+	flags.Parse(args)
+
+	keywordSet, ok := lookupKeywords(*keywords)
+	if !ok {
+		return exitUsage
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	programs, err := golden.Discover(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error searching the programs: "+err.Error())
+		return repl.EXIT_FAILURE
+	}
+
+	runner := golden.NewRunner()
+	runner.Keywords = keywordSet
+	runner.MaxTime = *maxTime
+
+	if *update {
+		for _, program := range programs {
+			if err := runner.Update(program); err != nil {
+				fmt.Fprintln(os.Stderr, "Error updating the expectation files: "+err.Error())
+				return repl.EXIT_FAILURE
+			}
+		}
+
+		fmt.Printf("Updated the expectation files of %d programs\n", len(programs))
+		return repl.EXIT_OK
+	}
+
+	failed := 0
+	for _, program := range programs {
+		result, err := runner.Check(program)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error checking the program: "+err.Error())
+			return repl.EXIT_FAILURE
+		}
+
+		if !result.Passed() {
+			result.WriteReport(os.Stdout)
+			failed++
+		}
+	}
+
+	if failed != 0 {
+		fmt.Printf("%d of %d programs differ from their expectation files\n", failed, len(programs))
+		return exitVerifyFailed
+	}
+
+	fmt.Printf("%d programs match their expectation files\n", len(programs))
+	return repl.EXIT_OK
+}